@@ -33,6 +33,10 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // CopyMethodType defines the methods for creating point-in-time copies of
 // volumes.
 // +kubebuilder:validation:Enum=Direct;None;Clone;Snapshot
@@ -62,6 +66,31 @@ const (
 	SynchronizingReasonError   string = "Error"
 )
 
+const (
+	ConditionSelfPeerConfigured string = "SelfPeerConfigured"
+	SelfPeerReasonIgnored       string = "SelfPeerIgnored"
+)
+
+const (
+	ConditionSynchronizeTimedOut      string = "SynchronizeTimedOut"
+	SynchronizeTimedOutReasonDeadline string = "DeadlineExceeded"
+)
+
+const (
+	ConditionNoPeersConfigured string = "NoPeersConfigured"
+	NoPeersReasonEmptyList     string = "EmptyPeerList"
+)
+
+const (
+	ConditionTooManyPeers      string = "TooManyPeers"
+	TooManyPeersReasonExceeded string = "MaxPeersExceeded"
+)
+
+const (
+	ConditionConfigNotPersisting    string = "ConfigNotPersisting"
+	ConfigNotPersistingReasonDiffer string = "ReadBackDiffers"
+)
+
 // SyncthingPeer Defines the necessary information needed by VolSync
 // to configure a given peer with the running Syncthing instance.
 type SyncthingPeer struct {
@@ -75,6 +104,22 @@ type SyncthingPeer struct {
 	// set each other as introducers as you will have a difficult time
 	// disconnecting the two.
 	Introducer bool `json:"introducer"`
+	// When to compress the data sent to this peer, one of: metadata, always, or never.
+	// Defaults to metadata.
+	//+kubebuilder:validation:Enum=metadata;always;never
+	//+optional
+	Compression string `json:"compression,omitempty"`
+	// Whether the connection to this peer is paused.
+	//+optional
+	Paused bool `json:"paused,omitempty"`
+	// Maximum rate, in KiB/s, at which data is sent to this peer. Unlimited when 0.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxSendKbps int32 `json:"maxSendKbps,omitempty"`
+	// Maximum rate, in KiB/s, at which data is received from this peer. Unlimited when 0.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxRecvKbps int32 `json:"maxRecvKbps,omitempty"`
 }
 
 // SyncthingPeerStatus Is a struct that contains information pertaining to
@@ -90,6 +135,86 @@ type SyncthingPeerStatus struct {
 	IntroducedBy string `json:"introducedBy,omitempty"`
 	// A friendly name to associate the given device.
 	Name string `json:"name,omitempty"`
+	// When the peer was last seen by Syncthing.
+	//+optional
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+	// Flag indicating that the peer hasn't been seen within the stalePeerThreshold.
+	//+optional
+	Stale bool `json:"stale,omitempty"`
+	// Total time the peer has been connected, accumulated across the status updates made by VolSync.
+	//+optional
+	ConnectedDuration *metav1.Duration `json:"connectedDuration,omitempty"`
+	// Total number of bytes received from the peer over its current connection. Zero while the
+	// peer has never been connected.
+	//+optional
+	InBytesTotal int64 `json:"inBytesTotal,omitempty"`
+	// Total number of bytes sent to the peer over its current connection. Zero while the peer
+	// has never been connected.
+	//+optional
+	OutBytesTotal int64 `json:"outBytesTotal,omitempty"`
+	// Whether the peer's address could be dialed from the VolSync controller during the last
+	// reconcile. Only reported when checkPeerReachability is set.
+	//+optional
+	Reachable *bool `json:"reachable,omitempty"`
+}
+
+// States reported for a Syncthing folder. Syncthing's own folder states are
+// normalized into this set.
+const (
+	SyncthingFolderStateIdle     = "Idle"
+	SyncthingFolderStateScanning = "Scanning"
+	SyncthingFolderStateSyncing  = "Syncing"
+	SyncthingFolderStateError    = "Error"
+	SyncthingFolderStateUnknown  = "Unknown"
+)
+
+// SyncthingWorkloadType Is the kind of workload used to run the Syncthing mover.
+// +kubebuilder:validation:Enum=Deployment;StatefulSet
+type SyncthingWorkloadType string
+
+const (
+	// SyncthingWorkloadDeployment Runs the mover as a Deployment.
+	SyncthingWorkloadDeployment SyncthingWorkloadType = "Deployment"
+	// SyncthingWorkloadStatefulSet Runs the mover as a single-replica StatefulSet, giving the
+	// mover's pod a stable DNS name through a headless Service.
+	SyncthingWorkloadStatefulSet SyncthingWorkloadType = "StatefulSet"
+)
+
+// SyncthingFolderETAUnknown Is reported as the ETA of a syncing folder
+// while no transfer rate has been observed yet.
+const SyncthingFolderETAUnknown = "Unknown"
+
+// SyncthingFolderStatus Is a struct that contains information pertaining to
+// the status of a folder shared by Syncthing.
+type SyncthingFolderStatus struct {
+	// ID Is the folder's Syncthing ID.
+	ID string `json:"ID"`
+	// State of the folder, one of: Idle, Scanning, Syncing, Error, or Unknown.
+	//+optional
+	State string `json:"state,omitempty"`
+	// Number of conflicting files found in the folder.
+	Conflicts int32 `json:"conflicts"`
+	// Paths of the conflicting files within the folder, limited to the first 10 found.
+	//+optional
+	ConflictingFiles []string `json:"conflictingFiles,omitempty"`
+	// Number of bytes the folder still needs to receive from its peers.
+	//+optional
+	NeedBytes int64 `json:"needBytes,omitempty"`
+	// Number of files in the global state of the folder, as known across all of its peers.
+	// While the folder is still being scanned, this only covers what has been scanned so far.
+	//+optional
+	GlobalFiles int64 `json:"globalFiles,omitempty"`
+	// Total size in bytes of the global state of the folder, as known across all of its peers.
+	// While the folder is still being scanned, this only covers what has been scanned so far.
+	//+optional
+	GlobalBytes int64 `json:"globalBytes,omitempty"`
+	// Estimated time remaining until the folder is in sync, e.g. 1m30s. Only reported while
+	// the folder is syncing, and Unknown until a transfer rate has been observed.
+	//+optional
+	ETA string `json:"eta,omitempty"`
+	// Device IDs of the peers the folder is shared with, as found in Syncthing's config.
+	//+optional
+	SharedWith []string `json:"sharedWith,omitempty"`
 }
 
 type MoverResult string
@@ -30,6 +30,13 @@ const (
 	EvRPVCNotBound     = "PersistentVolumeClaimNotBound" // Warning
 	EvRSvcAddress      = "ServiceAddressAssigned"
 	EvRSvcNoAddress    = "NoServiceAddressAssigned" // Warning
+	EvRSvcAPIExposed   = "ServiceExposesAPI"        // Warning
+	EvRPeerStale       = "PeerStale"                // Warning
+	EvRMoverNotPriv    = "MoverNotPrivileged"       // Warning
+	EvRSelfPeer        = "SelfPeerConfigured"       // Warning
+	EvRIndexTooLarge   = "IndexSizeExceeded"        // Warning
+	EvRSyncthingConfig = "SyncthingConfigUpdated"
+	EvRSyncthingAPI    = "SyncthingAPIError" // Warning
 )
 
 // ReplicationSource/ReplicationDestination Event "action" strings: Things the controller "does"
@@ -36,6 +36,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -230,18 +231,82 @@ type ReplicationSourceResticStatus struct {
 type ReplicationSourceSyncthingSpec struct {
 	// List of Syncthing peers to be connected for syncing
 	Peers []SyncthingPeer `json:"peers,omitempty"`
+	// Maximum number of peers that this Syncthing instance may be configured with.
+	// The peer list isn't applied while it exceeds it, which is reported through the TooManyPeers
+	// condition. Unlimited if unset.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	MaxPeers *int32 `json:"maxPeers,omitempty"`
 	// Type of service to be used when exposing the Syncthing peer
 	//+optional
 	ServiceType *corev1.ServiceType `json:"serviceType,omitempty"`
+	// When set, the Syncthing API port is also exposed on the data Service. With a
+	// LoadBalancer this makes the admin API reachable from outside the cluster, so it
+	// should only be enabled for remote administration. Defaults to "false".
+	//+optional
+	ExposeAPI bool `json:"exposeAPI,omitempty"`
+	// When set, no Service is created for the Syncthing API, and a NetworkPolicy only admits
+	// connections to the API port from the namespace VolSync runs in, while a sidecar in the mover's
+	// pod can still reach it over localhost. VolSync then connects to the API through the IP of the
+	// mover's pod. Can't be combined with exposeAPI. Defaults to "false".
+	//+optional
+	PodLocalAPI bool `json:"podLocalAPI,omitempty"`
+	// Fixed node port for the Syncthing data port. Only used when serviceType is
+	// NodePort; a port is allocated by the cluster if unset.
+	//+kubebuilder:validation:Minimum=30000
+	//+kubebuilder:validation:Maximum=32767
+	//+optional
+	DataNodePort *int32 `json:"dataNodePort,omitempty"`
+	// Fixed node port for the Syncthing API port. Only used when serviceType is
+	// NodePort and exposeAPI is set; a port is allocated by the cluster if unset.
+	//+kubebuilder:validation:Minimum=30000
+	//+kubebuilder:validation:Maximum=32767
+	//+optional
+	APINodePort *int32 `json:"apiNodePort,omitempty"`
+	// When set, the data port is bound as a hostPort on the node running the mover, and
+	// the node's address is advertised to peers. The mover is kept on the node it was
+	// first scheduled to so that the address stays stable. Defaults to "false".
+	//+optional
+	UseHostPort bool `json:"useHostPort,omitempty"`
+	// Sources allowed to connect to the Syncthing data port, as CIDRs or namespace & pod selectors.
+	// When set, a NetworkPolicy is created which denies connections to the data port from any
+	// other source. The API port remains reachable, as it is used by VolSync.
+	//+optional
+	AllowedDataSources []networkingv1.NetworkPolicyPeer `json:"allowedDataSources,omitempty"`
 	// Used to set the size of the Syncthing config volume.
 	//+optional
 	ConfigCapacity *resource.Quantity `json:"configCapacity,omitempty"`
+	// When set, the PVC storing Syncthing's configuration data is grown with the number of
+	// folders shared by Syncthing, starting from configCapacity. The PVC is never shrunk.
+	// Defaults to "false".
+	//+optional
+	AutoSizeConfig bool `json:"autoSizeConfig,omitempty"`
+	// Size of Syncthing's index database past which a warning event recommends a larger config
+	// volume, so a small volume doesn't silently fill up. The size is estimated from the number of
+	// files and bytes in the folders. No warning is emitted when unspecified.
+	//+optional
+	MaxIndexSize *resource.Quantity `json:"maxIndexSize,omitempty"`
+	// When set, the devices and folders VolSync configures in Syncthing are rendered into a
+	// ConfigMap on every reconcile, with credentials redacted, so they can be reviewed.
+	// Defaults to "false".
+	//+optional
+	RenderConfig bool `json:"renderConfig,omitempty"`
 	// Used to set the StorageClass of the Syncthing config volume.
 	//+optional
 	ConfigStorageClassName *string `json:"configStorageClassName,omitempty"`
 	// Used to set the accessModes of Syncthing config volume.
 	//+optional
 	ConfigAccessModes []corev1.PersistentVolumeAccessMode `json:"configAccessModes,omitempty"`
+	// Name of the Pod volume holding Syncthing's config. Defaults to "syncthing-config".
+	//+kubebuilder:validation:MaxLength=63
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+optional
+	ConfigVolumeName *string `json:"configVolumeName,omitempty"`
+	// Name of the Pod volume holding the data being synced. Defaults to "syncthing-data".
+	//+kubebuilder:validation:MaxLength=63
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+optional
+	DataVolumeName *string `json:"dataVolumeName,omitempty"`
 	// MoverSecurityContext allows specifying the PodSecurityContext that will
 	// be used by the data mover
 	MoverSecurityContext *corev1.PodSecurityContext `json:"moverSecurityContext,omitempty"`
@@ -251,6 +316,341 @@ type ReplicationSourceSyncthingSpec struct {
 	// The service account needs to exist in the same namespace as the ReplicationSource.
 	//+optional
 	MoverServiceAccount *string `json:"moverServiceAccount,omitempty"`
+	// Name of the scheduler that will schedule the mover Pod. When unspecified,
+	// the cluster's default scheduler is used.
+	//+optional
+	SchedulerName *string `json:"schedulerName,omitempty"`
+	// Whether the env vars of the Services in the namespace are injected into the mover Pod. These are
+	// noisy and may collide with Syncthing's own env vars, so they are disabled by default.
+	//+kubebuilder:default=false
+	//+optional
+	EnableServiceLinks *bool `json:"enableServiceLinks,omitempty"`
+	// Image used for the Syncthing container in place of the one the VolSync controller is
+	// configured with, e.g. a copy held in a mirrored registry. Pinning the image by digest
+	// avoids pulling it on every start. Can't be set while the mover is privileged.
+	//+optional
+	MoverImage *string `json:"moverImage,omitempty"`
+	// Pull policy of the Syncthing container image. When unspecified, images pinned by digest are
+	// only pulled when not present, while images referenced by a tag are always pulled.
+	//+kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	//+optional
+	ImagePullPolicy *corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// Compute resources requested by, and limits applied to, the Syncthing container. When
+	// unspecified, the container's memory is limited to 1Gi.
+	//+optional
+	MoverResources *corev1.ResourceRequirements `json:"moverResources,omitempty"`
+	// Sysctls set on the mover Pod, e.g. to tune socket buffers for high-throughput replication.
+	// Only namespaced sysctls may be used. Sysctls which are not considered safe by Kubernetes must
+	// also be allowed by the kubelet, or the Pod will be rejected.
+	//+optional
+	Sysctls []corev1.Sysctl `json:"sysctls,omitempty"`
+	// The kind of workload used to run Syncthing, either Deployment or StatefulSet. With a
+	// StatefulSet, a headless Service gives the mover's pod a stable DNS name, which is reported
+	// as the address for peers within the cluster. Defaults to Deployment.
+	//+optional
+	WorkloadType *SyncthingWorkloadType `json:"workloadType,omitempty"`
+	// How the termination message of the Syncthing container is populated. Defaults to
+	// FallbackToLogsOnError, so the last lines of the log are surfaced in the Pod's status
+	// when Syncthing crashes.
+	//+kubebuilder:validation:Enum=File;FallbackToLogsOnError
+	//+optional
+	TerminationMessagePolicy *corev1.TerminationMessagePolicy `json:"terminationMessagePolicy,omitempty"`
+	// Options easing the interactive debugging of the Syncthing container. These are meant to
+	// be set temporarily, and are disabled when unspecified.
+	//+optional
+	Debug *SyncthingDebugSpec `json:"debug,omitempty"`
+	// Name of a Secret of type kubernetes.io/tls holding the certificate & key served by
+	// the Syncthing API, in place of the self-signed certificate generated by VolSync.
+	// The certificate must be valid for the API Service's DNS name. If the Secret has a
+	// ca.crt, it is used by VolSync to verify the certificate.
+	//+optional
+	APICertificateSecret *string `json:"apiCertificateSecret,omitempty"`
+	// Path prefix prepended to every request VolSync makes to the Syncthing API, for when the API
+	// is served under a path prefix, e.g. by a reverse-proxy, such as /syncthing.
+	//+kubebuilder:validation:Pattern=`^/`
+	//+optional
+	APIPathPrefix *string `json:"apiPathPrefix,omitempty"`
+	// Name of a Secret holding the passwords the folders are encrypted with when shared with
+	// untrusted peers, keyed by the peer's Syncthing ID. The passwords of peers missing from the
+	// Secret are left untouched.
+	//+optional
+	EncryptionPasswordSecret *string `json:"encryptionPasswordSecret,omitempty"`
+	// Address that will be reported in the status as the address peers should use to
+	// connect to this Syncthing instance, in place of the address derived from the
+	// data Service. This is useful when the Service is reached through NAT or a
+	// port-forward. Must be a valid Syncthing address, e.g. tcp://example.com:22000
+	//+optional
+	AdvertisedAddress *string `json:"advertisedAddress,omitempty"`
+	// Name given to this Syncthing instance in its own config, which peers may show for it, in place
+	// of the name Syncthing derives from the hostname of the mover's pod. The name is restored when it
+	// is changed in Syncthing. Syncthing's name is left untouched when unspecified.
+	//+optional
+	LocalDeviceName *string `json:"localDeviceName,omitempty"`
+	// When set, the address reported in the status is also written to the
+	// volsync.backube/syncthing-address annotation on the ReplicationSource, for tools
+	// which read annotations rather than the status. Defaults to "false".
+	//+optional
+	AnnotateAddress bool `json:"annotateAddress,omitempty"`
+	// When set, the objects created for the mover are annotated with the UID of the
+	// ReplicationSource in volsync.backube/owner-uid, telling them apart from objects left
+	// over by a deleted ReplicationSource of the same name. Defaults to "false".
+	//+optional
+	AnnotateOwnerUID bool `json:"annotateOwnerUID,omitempty"`
+	// When set, the mover container will not be considered started until the Syncthing
+	// API reports healthy, or until this many seconds have passed. This reduces failed
+	// API calls while Syncthing loads large indexes on a cold start.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	StartupHealthTimeoutSeconds *int32 `json:"startupHealthTimeoutSeconds,omitempty"`
+	// Path of the API endpoint checked by the startup health check and the readiness probe, for custom
+	// Syncthing builds or proxies which report health elsewhere. Defaults to "/rest/noauth/health".
+	//+kubebuilder:validation:Pattern=`^/[^\s]*$`
+	//+optional
+	HealthCheckPath *string `json:"healthCheckPath,omitempty"`
+	// How long a disconnected peer may go unseen before it is flagged as stale in the status,
+	// and a warning event is emitted. Peers are never flagged as stale when unspecified.
+	//+optional
+	StalePeerThreshold *metav1.Duration `json:"stalePeerThreshold,omitempty"`
+	// When set, VolSync dials the address of each peer from the controller on every reconcile,
+	// and reports in the status whether it was reachable. This tells a broken network apart from
+	// Syncthing not retrying the connection. Defaults to "false".
+	//+optional
+	CheckPeerReachability bool `json:"checkPeerReachability,omitempty"`
+	// How often the key used to access the Syncthing API is replaced by a newly generated one.
+	// Syncthing is restarted with the new key each time it is rotated. The key is never rotated
+	// when unspecified.
+	//+optional
+	APIKeyRotationInterval *metav1.Duration `json:"apiKeyRotationInterval,omitempty"`
+	// Key of the VolSync-managed API Secret under which the key used to access the Syncthing API
+	// is stored, for integrating with external secret tooling. Defaults to "apikey".
+	//+kubebuilder:validation:MaxLength=253
+	//+kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	//+optional
+	APIKeySecretKey *string `json:"apiKeySecretKey,omitempty"`
+	// How long a single synchronization pass may take before it is aborted and retried,
+	// so a stuck step can't hold up the reconcile. Defaults to 2 minutes.
+	//+optional
+	SynchronizeTimeout *metav1.Duration `json:"synchronizeTimeout,omitempty"`
+	// How long VolSync waits between synchronization passes, which configure Syncthing and update the
+	// status. Longer intervals reduce the load of large topologies, while shorter ones report changes
+	// sooner. Defaults to 20 seconds.
+	//+optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+	// When set, snapshots of the statistics reported in the status for each peer & folder are
+	// periodically written to ConfigMaps, keeping a bounded history for trend analysis without
+	// an external time series database.
+	//+optional
+	StatsSnapshots *SyncthingStatsSnapshotsSpec `json:"statsSnapshots,omitempty"`
+	// When set, VolumeSnapshots of the volume holding Syncthing's config and index database are
+	// periodically taken, so that a corrupt index can be restored from a recent snapshot rather
+	// than rebuilt through a full rescan.
+	//+optional
+	IndexSnapshots *SyncthingIndexSnapshotsSpec `json:"indexSnapshots,omitempty"`
+	// Whether VolSync manages Syncthing's folders. When false, only the devices are configured,
+	// and the folders, including the devices they are shared with, are left untouched for them to
+	// be managed externally. Defaults to true.
+	//+optional
+	ManageFolders *bool `json:"manageFolders,omitempty"`
+	// Options for the Syncthing folder holding the data being synced.
+	//+optional
+	Folder *SyncthingFolderSpec `json:"folder,omitempty"`
+	// Options applied to the Syncthing instance as a whole.
+	//+optional
+	Options *SyncthingOptionsSpec `json:"options,omitempty"`
+}
+
+// SyncthingDebugSpec defines the options easing the interactive debugging of the Syncthing container.
+type SyncthingDebugSpec struct {
+	// When set, stdin is kept open and a TTY is allocated for the Syncthing container, for
+	// debug images and tools which require an interactive terminal. Defaults to "false".
+	//+optional
+	TTY bool `json:"tty,omitempty"`
+	// When set, the Syncthing container sleeps rather than running Syncthing, so that it can
+	// be inspected with kubectl exec. Nothing is synced while it sleeps. Defaults to "false".
+	//+optional
+	Sleep bool `json:"sleep,omitempty"`
+}
+
+// SyncthingStatsSnapshotsSpec defines how often snapshots of the sync statistics are taken, and
+// how many of them are retained.
+type SyncthingStatsSnapshotsSpec struct {
+	// How often a snapshot of the statistics is taken, at least a minute apart. Defaults to 1 hour.
+	//+kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ns|us|ms|s|m|h))+$`
+	//+optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Number of snapshots retained, the oldest ones being removed first. Defaults to 24.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	Retain *int32 `json:"retain,omitempty"`
+}
+
+// SyncthingIndexSnapshotsSpec defines how often VolumeSnapshots of Syncthing's index database are
+// taken, and how many of them are retained.
+type SyncthingIndexSnapshotsSpec struct {
+	// How often a snapshot of the index is taken, at least an hour apart. Defaults to 24 hours.
+	//+kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ns|us|ms|s|m|h))+$`
+	//+optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Number of snapshots retained, the oldest ones being removed first. Defaults to 3.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	Retain *int32 `json:"retain,omitempty"`
+	// Name of the VolumeSnapshotClass used for the snapshots. The cluster's default class is used
+	// when unspecified.
+	//+optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// SyncthingOptionsSpec defines the global options applied to Syncthing. Options that
+// are left unset are not managed by VolSync.
+type SyncthingOptionsSpec struct {
+	// How often, in seconds, Syncthing updates the progress of ongoing transfers.
+	//+kubebuilder:validation:Minimum=1
+	//+optional
+	ProgressUpdateIntervalS *int32 `json:"progressUpdateIntervalS,omitempty"`
+	// Whether Syncthing announces its LAN addresses to peers, e.g. through local discovery.
+	//+optional
+	AnnounceLANAddresses *bool `json:"announceLANAddresses,omitempty"`
+	// Whether Syncthing uses local discovery to find and announce itself to peers on the LAN.
+	//+optional
+	LocalAnnounceEnabled *bool `json:"localAnnounceEnabled,omitempty"`
+	// Minimum number of blocks a file must have for its partially pulled blocks to be shared
+	// with other peers while it is still being pulled. Syncthing applies this to all folders.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	TempIndexMinBlocks *int32 `json:"tempIndexMinBlocks,omitempty"`
+	// Relays Syncthing is allowed to connect through, e.g. relay://relay.example.com:22067/?id=<relay ID>.
+	// When set, relaying is enabled through the listed relays only, in place of the public relay pool.
+	// Once cleared, the default listen addresses and the public relay pool are restored.
+	//+optional
+	RelayServers []string `json:"relayServers,omitempty"`
+	// Global discovery servers Syncthing announces itself to and looks up peers with, e.g.
+	// https://discovery.example.com:8443/?id=<server ID>. When set, global discovery is enabled
+	// through the listed servers only, in place of the public discovery servers.
+	//+optional
+	DiscoveryServers []string `json:"discoveryServers,omitempty"`
+	// How long, in hours, Syncthing keeps the temporary files of incomplete transfers before removing
+	// them. Keeping them allows an interrupted transfer to resume without pulling the file again.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	KeepTemporariesH *int32 `json:"keepTemporariesH,omitempty"`
+	// Maximum rate, in KiB/s, at which Syncthing sends data to all of its peers combined.
+	// Unlimited when 0, and left untouched when unset.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxSendKbps *int32 `json:"maxSendKbps,omitempty"`
+	// Maximum rate, in KiB/s, at which Syncthing receives data from all of its peers combined.
+	// Unlimited when 0, and left untouched when unset.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxRecvKbps *int32 `json:"maxRecvKbps,omitempty"`
+}
+
+// SyncthingFolderSpec defines the options applied to the folder Syncthing shares with its peers.
+type SyncthingFolderSpec struct {
+	// Label of the folder as shown in the Syncthing web UI. The folder ID is not
+	// affected, so the label can be changed without disrupting peers.
+	//+optional
+	Label string `json:"label,omitempty"`
+	// When set, deletions received from peers will not be applied to this folder.
+	// This is useful for backup-like semantics. Defaults to "false".
+	//+optional
+	IgnoreDelete bool `json:"ignoreDelete,omitempty"`
+	// Name of the file or directory that marks the root of the folder, which Syncthing
+	// requires to be present before syncing. Defaults to ".stfolder".
+	//+optional
+	MarkerName string `json:"markerName,omitempty"`
+	// When set, the extended attributes of files, e.g. SELinux labels or capabilities,
+	// are sent to peers. Defaults to "false".
+	//+optional
+	SendXattrs bool `json:"sendXattrs,omitempty"`
+	// When set, the extended attributes received from peers are applied to this folder.
+	// Defaults to "false".
+	//+optional
+	SyncXattrs bool `json:"syncXattrs,omitempty"`
+	// Ordered list of rules selecting the extended attributes that are synced. The first
+	// rule matching an attribute's name decides whether it is synced.
+	//+optional
+	XattrFilter []SyncthingXattrFilterEntry `json:"xattrFilter,omitempty"`
+	// Maximum number of files Syncthing writes to concurrently in this folder. Lowering it
+	// reduces thrashing on spinning disks or network filesystems. Defaults to 2 when 0 or unset.
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=64
+	//+optional
+	MaxConcurrentWrites int32 `json:"maxConcurrentWrites,omitempty"`
+	// Number of seconds by which modification times may differ while still being considered
+	// equal. Set it to 2 on filesystems with coarse timestamps, such as FAT. Defaults to 0.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	ModTimeWindowS int32 `json:"modTimeWindowS,omitempty"`
+	// When set, the ownership of new files and directories is copied from their parent
+	// directory. This requires a privileged mover. Defaults to "false".
+	//+optional
+	CopyOwnershipFromParent bool `json:"copyOwnershipFromParent,omitempty"`
+	// When set, the ownership (UID & GID) of files is sent to peers. Defaults to "false".
+	//+optional
+	SendOwnership bool `json:"sendOwnership,omitempty"`
+	// When set, the ownership received from peers is applied to the files of this folder. This
+	// requires a privileged mover. Defaults to "false".
+	//+optional
+	SyncOwnership bool `json:"syncOwnership,omitempty"`
+	// Maximum amount of data, in KiB, which may be pending while pulling files into this
+	// folder, bounding the memory used by large pulls. Chosen by Syncthing when 0 or unset.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	PullerMaxPendingKiB int32 `json:"pullerMaxPendingKiB,omitempty"`
+	// Order in which the blocks of a file are pulled, either standard, random or inOrder. Pulling
+	// blocks in order helps sequential-access workloads, such as media, to use files while they
+	// are being synced. Defaults to standard.
+	//+kubebuilder:validation:Enum=standard;random;inOrder
+	//+optional
+	BlockPullOrder string `json:"blockPullOrder,omitempty"`
+	// Type of the filesystem backing the folder, either basic or fake. The fake filesystem only
+	// simulates files, without storing any data, and is meant for testing. Defaults to basic.
+	//+kubebuilder:validation:Enum=basic;fake
+	//+optional
+	FilesystemType string `json:"filesystemType,omitempty"`
+	// Type of the folder, either sendreceive, sendonly or receiveonly. A receiveonly folder never
+	// sends its local changes to peers, which suits backup replicas. Defaults to sendreceive.
+	//+kubebuilder:validation:Enum=sendreceive;sendonly;receiveonly
+	//+optional
+	Type string `json:"type,omitempty"`
+	// How Syncthing keeps old versions of the files changed or deleted by peers, so that a change
+	// made on one peer can be undone on the others. No versions are kept when unset.
+	//+optional
+	Versioning *SyncthingVersioningSpec `json:"versioning,omitempty"`
+	// How often, in seconds, Syncthing rescans the folder for changes. Raising it spares large, slowly
+	// changing folders from constant rescans, while 0 disables periodic rescans. Syncthing's setting is
+	// left untouched when unset.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	RescanIntervalS *int32 `json:"rescanIntervalS,omitempty"`
+	// Whether Syncthing watches the folder for changes through the filesystem's notifications,
+	// reducing the need for full rescans. Disabling it avoids exhausting the inotify watches of
+	// nodes with low limits. Left untouched when unset, Syncthing enabling it by default.
+	//+optional
+	FSWatcherEnabled *bool `json:"fsWatcherEnabled,omitempty"`
+}
+
+// SyncthingVersioningSpec defines how Syncthing keeps old versions of the files changed or deleted by peers.
+type SyncthingVersioningSpec struct {
+	// Type of versioning, either trashcan, simple or staggered.
+	//+kubebuilder:validation:Enum=trashcan;simple;staggered
+	Type string `json:"type"`
+	// Parameters of the versioning type, e.g. cleanoutDays for trashcan versioning, keep & cleanoutDays
+	// for simple versioning, or maxAge for staggered versioning. Syncthing's defaults are used for the
+	// parameters left unset.
+	//+optional
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// SyncthingXattrFilterEntry defines a rule selecting the extended attributes synced by Syncthing.
+type SyncthingXattrFilterEntry struct {
+	// Glob pattern matched against the name of the extended attribute, e.g. "security.*".
+	Match string `json:"match"`
+	// Whether the extended attributes matching the pattern are synced.
+	//+optional
+	Permit bool `json:"permit,omitempty"`
 }
 
 // ReplicationSourceSpec defines the desired state of ReplicationSource
@@ -305,10 +705,39 @@ type ReplicationSourceRsyncStatus struct {
 type ReplicationSourceSyncthingStatus struct {
 	// List of the Syncthing nodes we are currently connected to.
 	Peers []SyncthingPeerStatus `json:"peers,omitempty"`
+	// When the status of the peers was last updated, used to accumulate their connected durations.
+	//+optional
+	PeersObservedTime *metav1.Time `json:"peersObservedTime,omitempty"`
 	// Device ID of the current syncthing device
 	ID string `json:"ID,omitempty"`
 	// Service address where Syncthing is exposed to the rest of the world
 	Address string `json:"address,omitempty"`
+	// Scheme of the address where Syncthing is exposed, e.g. tcp
+	//+optional
+	DataScheme string `json:"dataScheme,omitempty"`
+	// Host of the address where Syncthing is exposed
+	//+optional
+	DataHost string `json:"dataHost,omitempty"`
+	// Port of the address where Syncthing is exposed, unset when the address doesn't specify one
+	//+optional
+	DataPort int32 `json:"dataPort,omitempty"`
+	// Version of the configuration used by Syncthing, which is migrated by Syncthing when it
+	// changes across releases.
+	//+optional
+	ConfigVersion int32 `json:"configVersion,omitempty"`
+	// List of the folders shared by Syncthing.
+	Folders []SyncthingFolderStatus `json:"folders,omitempty"`
+	// Number of folders which are currently in an error state, or have failed to sync some items.
+	//+optional
+	FoldersInError int32 `json:"foldersInError,omitempty"`
+	// Estimated size in bytes of Syncthing's index database, computed from the number of files and
+	// bytes in the folders.
+	//+optional
+	EstimatedIndexBytes int64 `json:"estimatedIndexBytes,omitempty"`
+	// Whether the mover has reached a steady state: its pod is ready, the Syncthing API is reachable,
+	// Syncthing's configuration matches the spec, and all of the peers are connected.
+	//+optional
+	Ready bool `json:"ready"`
 }
 
 // ReplicationSourceStatus defines the observed state of ReplicationSource
@@ -40,6 +40,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -1092,16 +1093,43 @@ func (in *ReplicationSourceSyncthingSpec) DeepCopyInto(out *ReplicationSourceSyn
 		*out = make([]SyncthingPeer, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxPeers != nil {
+		in, out := &in.MaxPeers, &out.MaxPeers
+		*out = new(int32)
+		**out = **in
+	}
 	if in.ServiceType != nil {
 		in, out := &in.ServiceType, &out.ServiceType
 		*out = new(v1.ServiceType)
 		**out = **in
 	}
+	if in.DataNodePort != nil {
+		in, out := &in.DataNodePort, &out.DataNodePort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.APINodePort != nil {
+		in, out := &in.APINodePort, &out.APINodePort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllowedDataSources != nil {
+		in, out := &in.AllowedDataSources, &out.AllowedDataSources
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ConfigCapacity != nil {
 		in, out := &in.ConfigCapacity, &out.ConfigCapacity
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.MaxIndexSize != nil {
+		in, out := &in.MaxIndexSize, &out.MaxIndexSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 	if in.ConfigStorageClassName != nil {
 		in, out := &in.ConfigStorageClassName, &out.ConfigStorageClassName
 		*out = new(string)
@@ -1112,6 +1140,16 @@ func (in *ReplicationSourceSyncthingSpec) DeepCopyInto(out *ReplicationSourceSyn
 		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConfigVolumeName != nil {
+		in, out := &in.ConfigVolumeName, &out.ConfigVolumeName
+		*out = new(string)
+		**out = **in
+	}
+	if in.DataVolumeName != nil {
+		in, out := &in.DataVolumeName, &out.DataVolumeName
+		*out = new(string)
+		**out = **in
+	}
 	if in.MoverSecurityContext != nil {
 		in, out := &in.MoverSecurityContext, &out.MoverSecurityContext
 		*out = new(v1.PodSecurityContext)
@@ -1122,6 +1160,131 @@ func (in *ReplicationSourceSyncthingSpec) DeepCopyInto(out *ReplicationSourceSyn
 		*out = new(string)
 		**out = **in
 	}
+	if in.SchedulerName != nil {
+		in, out := &in.SchedulerName, &out.SchedulerName
+		*out = new(string)
+		**out = **in
+	}
+	if in.EnableServiceLinks != nil {
+		in, out := &in.EnableServiceLinks, &out.EnableServiceLinks
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MoverImage != nil {
+		in, out := &in.MoverImage, &out.MoverImage
+		*out = new(string)
+		**out = **in
+	}
+	if in.ImagePullPolicy != nil {
+		in, out := &in.ImagePullPolicy, &out.ImagePullPolicy
+		*out = new(v1.PullPolicy)
+		**out = **in
+	}
+	if in.MoverResources != nil {
+		in, out := &in.MoverResources, &out.MoverResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make([]v1.Sysctl, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkloadType != nil {
+		in, out := &in.WorkloadType, &out.WorkloadType
+		*out = new(SyncthingWorkloadType)
+		**out = **in
+	}
+	if in.TerminationMessagePolicy != nil {
+		in, out := &in.TerminationMessagePolicy, &out.TerminationMessagePolicy
+		*out = new(v1.TerminationMessagePolicy)
+		**out = **in
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(SyncthingDebugSpec)
+		**out = **in
+	}
+	if in.APICertificateSecret != nil {
+		in, out := &in.APICertificateSecret, &out.APICertificateSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.APIPathPrefix != nil {
+		in, out := &in.APIPathPrefix, &out.APIPathPrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.EncryptionPasswordSecret != nil {
+		in, out := &in.EncryptionPasswordSecret, &out.EncryptionPasswordSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.AdvertisedAddress != nil {
+		in, out := &in.AdvertisedAddress, &out.AdvertisedAddress
+		*out = new(string)
+		**out = **in
+	}
+	if in.LocalDeviceName != nil {
+		in, out := &in.LocalDeviceName, &out.LocalDeviceName
+		*out = new(string)
+		**out = **in
+	}
+	if in.StartupHealthTimeoutSeconds != nil {
+		in, out := &in.StartupHealthTimeoutSeconds, &out.StartupHealthTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StalePeerThreshold != nil {
+		in, out := &in.StalePeerThreshold, &out.StalePeerThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.APIKeyRotationInterval != nil {
+		in, out := &in.APIKeyRotationInterval, &out.APIKeyRotationInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.APIKeySecretKey != nil {
+		in, out := &in.APIKeySecretKey, &out.APIKeySecretKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.SynchronizeTimeout != nil {
+		in, out := &in.SynchronizeTimeout, &out.SynchronizeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.StatsSnapshots != nil {
+		in, out := &in.StatsSnapshots, &out.StatsSnapshots
+		*out = new(SyncthingStatsSnapshotsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IndexSnapshots != nil {
+		in, out := &in.IndexSnapshots, &out.IndexSnapshots
+		*out = new(SyncthingIndexSnapshotsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManageFolders != nil {
+		in, out := &in.ManageFolders, &out.ManageFolders
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Folder != nil {
+		in, out := &in.Folder, &out.Folder
+		*out = new(SyncthingFolderSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(SyncthingOptionsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceSyncthingSpec.
@@ -1140,7 +1303,20 @@ func (in *ReplicationSourceSyncthingStatus) DeepCopyInto(out *ReplicationSourceS
 	if in.Peers != nil {
 		in, out := &in.Peers, &out.Peers
 		*out = make([]SyncthingPeerStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PeersObservedTime != nil {
+		in, out := &in.PeersObservedTime, &out.PeersObservedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Folders != nil {
+		in, out := &in.Folders, &out.Folders
+		*out = make([]SyncthingFolderStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -1259,6 +1435,171 @@ func (in *ResticRetainPolicy) DeepCopy() *ResticRetainPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingDebugSpec) DeepCopyInto(out *SyncthingDebugSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingDebugSpec.
+func (in *SyncthingDebugSpec) DeepCopy() *SyncthingDebugSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingDebugSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingFolderSpec) DeepCopyInto(out *SyncthingFolderSpec) {
+	*out = *in
+	if in.XattrFilter != nil {
+		in, out := &in.XattrFilter, &out.XattrFilter
+		*out = make([]SyncthingXattrFilterEntry, len(*in))
+		copy(*out, *in)
+	}
+	if in.Versioning != nil {
+		in, out := &in.Versioning, &out.Versioning
+		*out = new(SyncthingVersioningSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RescanIntervalS != nil {
+		in, out := &in.RescanIntervalS, &out.RescanIntervalS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FSWatcherEnabled != nil {
+		in, out := &in.FSWatcherEnabled, &out.FSWatcherEnabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingFolderSpec.
+func (in *SyncthingFolderSpec) DeepCopy() *SyncthingFolderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingFolderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingFolderStatus) DeepCopyInto(out *SyncthingFolderStatus) {
+	*out = *in
+	if in.ConflictingFiles != nil {
+		in, out := &in.ConflictingFiles, &out.ConflictingFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SharedWith != nil {
+		in, out := &in.SharedWith, &out.SharedWith
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingFolderStatus.
+func (in *SyncthingFolderStatus) DeepCopy() *SyncthingFolderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingFolderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingIndexSnapshotsSpec) DeepCopyInto(out *SyncthingIndexSnapshotsSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Retain != nil {
+		in, out := &in.Retain, &out.Retain
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingIndexSnapshotsSpec.
+func (in *SyncthingIndexSnapshotsSpec) DeepCopy() *SyncthingIndexSnapshotsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingIndexSnapshotsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingOptionsSpec) DeepCopyInto(out *SyncthingOptionsSpec) {
+	*out = *in
+	if in.ProgressUpdateIntervalS != nil {
+		in, out := &in.ProgressUpdateIntervalS, &out.ProgressUpdateIntervalS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AnnounceLANAddresses != nil {
+		in, out := &in.AnnounceLANAddresses, &out.AnnounceLANAddresses
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LocalAnnounceEnabled != nil {
+		in, out := &in.LocalAnnounceEnabled, &out.LocalAnnounceEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TempIndexMinBlocks != nil {
+		in, out := &in.TempIndexMinBlocks, &out.TempIndexMinBlocks
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RelayServers != nil {
+		in, out := &in.RelayServers, &out.RelayServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DiscoveryServers != nil {
+		in, out := &in.DiscoveryServers, &out.DiscoveryServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeepTemporariesH != nil {
+		in, out := &in.KeepTemporariesH, &out.KeepTemporariesH
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSendKbps != nil {
+		in, out := &in.MaxSendKbps, &out.MaxSendKbps
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRecvKbps != nil {
+		in, out := &in.MaxRecvKbps, &out.MaxRecvKbps
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingOptionsSpec.
+func (in *SyncthingOptionsSpec) DeepCopy() *SyncthingOptionsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingOptionsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncthingPeer) DeepCopyInto(out *SyncthingPeer) {
 	*out = *in
@@ -1277,6 +1618,20 @@ func (in *SyncthingPeer) DeepCopy() *SyncthingPeer {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncthingPeerStatus) DeepCopyInto(out *SyncthingPeerStatus) {
 	*out = *in
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.ConnectedDuration != nil {
+		in, out := &in.ConnectedDuration, &out.ConnectedDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Reachable != nil {
+		in, out := &in.Reachable, &out.Reachable
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingPeerStatus.
@@ -1288,3 +1643,65 @@ func (in *SyncthingPeerStatus) DeepCopy() *SyncthingPeerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingStatsSnapshotsSpec) DeepCopyInto(out *SyncthingStatsSnapshotsSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Retain != nil {
+		in, out := &in.Retain, &out.Retain
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingStatsSnapshotsSpec.
+func (in *SyncthingStatsSnapshotsSpec) DeepCopy() *SyncthingStatsSnapshotsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingStatsSnapshotsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingVersioningSpec) DeepCopyInto(out *SyncthingVersioningSpec) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingVersioningSpec.
+func (in *SyncthingVersioningSpec) DeepCopy() *SyncthingVersioningSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingVersioningSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncthingXattrFilterEntry) DeepCopyInto(out *SyncthingXattrFilterEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncthingXattrFilterEntry.
+func (in *SyncthingXattrFilterEntry) DeepCopy() *SyncthingXattrFilterEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncthingXattrFilterEntry)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -33,6 +33,7 @@ type volsyncMetrics struct {
 	MissedIntervals prometheus.Counter
 	OutOfSync       prometheus.Gauge
 	SyncDurations   prometheus.Observer
+	FoldersInError  prometheus.Gauge
 }
 
 var (
@@ -69,6 +70,14 @@ var (
 		},
 		metricLabels,
 	)
+	foldersInError = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "syncthing_folders_in_error",
+			Namespace: metricsNamespace,
+			Help:      "The number of Syncthing folders currently in an error state",
+		},
+		metricLabels,
+	)
 )
 
 func newVolSyncMetrics(labels prometheus.Labels) volsyncMetrics {
@@ -76,10 +85,21 @@ func newVolSyncMetrics(labels prometheus.Labels) volsyncMetrics {
 		MissedIntervals: missedIntervals.With(labels),
 		OutOfSync:       outOfSync.With(labels),
 		SyncDurations:   syncDurations.With(labels),
+		FoldersInError:  foldersInError.With(labels),
 	}
 }
 
+// deleteSourceMetrics Removes the per-source series which would otherwise keep reporting the last
+// value of a ReplicationSource once it's deleted, whichever method it used.
+func deleteSourceMetrics(name string, namespace string) {
+	foldersInError.DeletePartialMatch(prometheus.Labels{
+		"obj_name":      name,
+		"obj_namespace": namespace,
+		"role":          "source",
+	})
+}
+
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(missedIntervals, outOfSync, syncDurations)
+	metrics.Registry.MustRegister(missedIntervals, outOfSync, syncDurations, foldersInError)
 }
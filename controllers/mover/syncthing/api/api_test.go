@@ -1,12 +1,27 @@
 package api
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/protocol"
 )
@@ -57,7 +72,7 @@ var _ = Describe("Syncthing connection", func() {
 				})
 
 				It("fetches the Latest Info", func() {
-					syncthing, err := syncthingConnection.Fetch()
+					syncthing, err := syncthingConnection.Fetch(context.TODO())
 					Expect(err).NotTo(HaveOccurred())
 					Expect(syncthing).NotTo(BeNil())
 
@@ -75,11 +90,150 @@ var _ = Describe("Syncthing connection", func() {
 					}
 
 					// write to the server
-					err := syncthingConnection.PublishConfig(syncthing.Configuration)
+					err := syncthingConnection.PublishConfig(context.TODO(), syncthing.Configuration)
 					Expect(err).To(BeNil())
 					Expect(serverState.Configuration.Version).To(Equal(9))
 				})
 
+				When("devices have been seen", func() {
+					BeforeEach(func() {
+						serverState.DeviceStats = map[string]DeviceStats{
+							myID.GoString(): {LastSeen: "2023-07-01T12:00:00Z", LastConnectionDurationS: 42},
+						}
+					})
+
+					It("fetches their statistics", func() {
+						syncthing, err := syncthingConnection.Fetch(context.TODO())
+						Expect(err).NotTo(HaveOccurred())
+						Expect(syncthing.DeviceStats).To(HaveKey(myID.GoString()))
+						Expect(syncthing.DeviceStats[myID.GoString()].LastSeen).To(Equal("2023-07-01T12:00:00Z"))
+					})
+				})
+
+				When("folders are shared", func() {
+					BeforeEach(func() {
+						serverState.Configuration.Folders = []config.FolderConfiguration{{ID: "festivus"}}
+						serverState.FolderEntries = map[string][]FileEntry{
+							"festivus": {{Name: "aluminum-pole.txt", Type: "FILE_INFO_TYPE_FILE"}},
+						}
+						serverState.FolderStatuses = map[string]FolderStatus{
+							"festivus": {State: "syncing", NeedBytes: 1024},
+						}
+					})
+
+					It("fetches neither their status nor their contents", func() {
+						syncthing, err := syncthingConnection.Fetch(context.TODO())
+						Expect(err).NotTo(HaveOccurred())
+						Expect(syncthing.FolderEntries).To(BeEmpty())
+						Expect(syncthing.FolderStatuses).To(BeEmpty())
+					})
+
+					It("fetches their status separately", func() {
+						status, err := syncthingConnection.FetchFolderStatus(context.TODO(), "festivus")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(status.State).To(Equal("syncing"))
+						Expect(status.NeedBytes).To(Equal(int64(1024)))
+					})
+
+					It("fetches their contents down to the given depth", func() {
+						serverState.FolderEntries["festivus"] = append(serverState.FolderEntries["festivus"], FileEntry{
+							Name: "grievances",
+							Type: "FILE_INFO_TYPE_DIRECTORY",
+							Children: []FileEntry{{
+								Name:     "1997",
+								Type:     "FILE_INFO_TYPE_DIRECTORY",
+								Children: []FileEntry{{Name: "george.txt", Type: "FILE_INFO_TYPE_FILE"}},
+							}},
+						})
+
+						entries, err := syncthingConnection.FetchFolderEntries(context.TODO(), "festivus", 1)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(entries).To(HaveLen(2))
+						Expect(entries[0].Name).To(Equal("aluminum-pole.txt"))
+						Expect(entries[1].Children).To(HaveLen(1))
+						Expect(entries[1].Children[0].Name).To(Equal("1997"))
+						Expect(entries[1].Children[0].Children).To(BeEmpty())
+					})
+				})
+			})
+
+			When("the connections of Syncthing can't be fetched", func() {
+				var proxy *httptest.Server
+				var syncthingConnection SyncthingConnection
+
+				JustBeforeEach(func() {
+					proxy = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if r.URL.Path == SystemConnectionsEndpoint {
+							http.Error(w, "the connections are unavailable", http.StatusInternalServerError)
+							return
+						}
+						ts.Config.Handler.ServeHTTP(w, r)
+					}))
+					syncthingConnection = NewConnection(APIConfig{
+						APIURL: proxy.URL,
+						APIKey: serverAPIKey,
+						Client: proxy.Client(),
+					}, logr.Discard().WithName("syncthing-api"))
+				})
+
+				JustAfterEach(func() {
+					proxy.Close()
+				})
+
+				It("fails rather than reporting every peer as disconnected", func() {
+					syncthing, err := syncthingConnection.Fetch(context.TODO())
+					Expect(err).To(HaveOccurred())
+					Expect(syncthing).To(BeNil())
+				})
+			})
+
+			When("the API is served under a path prefix", func() {
+				var proxy *httptest.Server
+				var requestedPaths []string
+				var syncthingConnection SyncthingConnection
+
+				BeforeEach(func() {
+					requestedPaths = []string{}
+					serverState.Configuration.Folders = []config.FolderConfiguration{{ID: "festivus"}}
+				})
+
+				JustBeforeEach(func() {
+					// mimic a reverse-proxy serving the API under /syncthing
+					proxy = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						requestedPaths = append(requestedPaths, r.URL.Path)
+						http.StripPrefix("/syncthing", ts.Config.Handler).ServeHTTP(w, r)
+					}))
+					syncthingConnection = NewConnection(APIConfig{
+						APIURL:        proxy.URL,
+						APIKey:        serverAPIKey,
+						APIPathPrefix: "/syncthing/",
+						Client:        proxy.Client(),
+					}, logr.Discard().WithName("syncthing-api"))
+				})
+
+				JustAfterEach(func() {
+					proxy.Close()
+				})
+
+				It("prepends the prefix to all REST URLs", func() {
+					syncthing, err := syncthingConnection.Fetch(context.TODO())
+					Expect(err).NotTo(HaveOccurred())
+					Expect(syncthingConnection.PublishConfig(context.TODO(), syncthing.Configuration)).To(Succeed())
+					_, err = syncthingConnection.FetchFolderEntries(context.TODO(), "festivus", 0)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = syncthingConnection.FetchFolderStatus(context.TODO(), "festivus")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(requestedPaths).To(ConsistOf(
+						"/syncthing"+ConfigEndpoint,
+						"/syncthing"+SystemConnectionsEndpoint,
+						"/syncthing"+SystemStatusEndpoint,
+						"/syncthing"+DeviceStatsEndpoint,
+						"/syncthing"+DBBrowseEndpoint,
+						"/syncthing"+DBStatusEndpoint,
+						"/syncthing"+ConfigEndpoint,
+					))
+				})
 			})
 
 			When("syncthingAPIConnection is making requests to the server", func() {
@@ -99,33 +253,33 @@ var _ = Describe("Syncthing connection", func() {
 				// nolint:dupl
 				It("jsonRequests without errors", func() {
 					// all of these request methods should succeed
-					_, err := apiConnection.jsonRequest(ConfigEndpoint, "GET", nil)
+					_, err := apiConnection.jsonRequest(context.TODO(), ConfigEndpoint, "GET", nil)
 					Expect(err).To(BeNil())
 
-					_, err = apiConnection.jsonRequest(SystemStatusEndpoint, "GET", nil)
+					_, err = apiConnection.jsonRequest(context.TODO(), SystemStatusEndpoint, "GET", nil)
 					Expect(err).To(BeNil())
 
-					_, err = apiConnection.jsonRequest(SystemConnectionsEndpoint, "GET", nil)
+					_, err = apiConnection.jsonRequest(context.TODO(), SystemConnectionsEndpoint, "GET", nil)
 					Expect(err).To(BeNil())
 
-					stConfig, err := apiConnection.fetchConfig()
+					stConfig, err := apiConnection.fetchConfig(context.TODO())
 					Expect(err).NotTo(HaveOccurred())
 					Expect(stConfig).NotTo(BeNil())
 
-					connections, err := apiConnection.fetchSystemConnections()
+					connections, err := apiConnection.fetchSystemConnections(context.TODO())
 					Expect(err).NotTo(HaveOccurred())
 					Expect(connections).NotTo(BeNil())
 
-					status, err := apiConnection.fetchSystemStatus()
+					status, err := apiConnection.fetchSystemStatus(context.TODO())
 					Expect(err).NotTo(HaveOccurred())
 					Expect(status).NotTo(BeNil())
 
 					mockConfig := config.Configuration{Version: 74}
-					err = apiConnection.PublishConfig(mockConfig)
+					err = apiConnection.PublishConfig(context.TODO(), mockConfig)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(serverState.Configuration.Version).To(Equal(mockConfig.Version))
 
-					syncthingResponse, err := apiConnection.Fetch()
+					syncthingResponse, err := apiConnection.Fetch(context.TODO())
 					Expect(err).NotTo(HaveOccurred())
 					Expect(syncthingResponse).NotTo(BeNil())
 
@@ -139,44 +293,104 @@ var _ = Describe("Syncthing connection", func() {
 					// nolint:dupl
 					It("errors", func() {
 						// ensure all of the api methods & helpers error here
-						_, err := apiConnection.jsonRequest(ConfigEndpoint, "GET", nil)
+						_, err := apiConnection.jsonRequest(context.TODO(), ConfigEndpoint, "GET", nil)
 						Expect(err).To(HaveOccurred())
 
-						_, err = apiConnection.jsonRequest(SystemStatusEndpoint, "GET", nil)
+						_, err = apiConnection.jsonRequest(context.TODO(), SystemStatusEndpoint, "GET", nil)
 						Expect(err).To(HaveOccurred())
 
-						_, err = apiConnection.jsonRequest(SystemConnectionsEndpoint, "GET", nil)
+						_, err = apiConnection.jsonRequest(context.TODO(), SystemConnectionsEndpoint, "GET", nil)
 						Expect(err).To(HaveOccurred())
 
-						stConfig, err := apiConnection.fetchConfig()
+						stConfig, err := apiConnection.fetchConfig(context.TODO())
 						Expect(err).To(HaveOccurred())
 						Expect(stConfig).To(BeNil())
 
-						connections, err := apiConnection.fetchSystemConnections()
+						connections, err := apiConnection.fetchSystemConnections(context.TODO())
 						Expect(err).To(HaveOccurred())
 						Expect(connections).To(BeNil())
 
-						status, err := apiConnection.fetchSystemStatus()
+						status, err := apiConnection.fetchSystemStatus(context.TODO())
 						Expect(err).To(HaveOccurred())
 						Expect(status).To(BeNil())
 
 						mockConfig := config.Configuration{Version: 74}
-						err = apiConnection.PublishConfig(mockConfig)
+						err = apiConnection.PublishConfig(context.TODO(), mockConfig)
 						Expect(err).To(HaveOccurred())
 						Expect(serverState.Configuration.Version).NotTo(Equal(mockConfig.Version))
 
-						syncthingResponse, err := apiConnection.Fetch()
+						syncthingResponse, err := apiConnection.Fetch(context.TODO())
 						Expect(err).To(HaveOccurred())
 						Expect(syncthingResponse).To(BeNil())
 					})
 				})
 
+				When("the server isn't listening", func() {
+					It("reports the API as unavailable", func() {
+						// errors returned by a running server don't count
+						_, err := apiConnection.jsonRequest(context.TODO(), "/rest/bogus", "GET", nil)
+						Expect(err).To(HaveOccurred())
+						Expect(IsUnavailable(err)).To(BeFalse())
+
+						ts.Close()
+						_, err = apiConnection.jsonRequest(context.TODO(), SystemStatusEndpoint, "GET", nil)
+						Expect(err).To(HaveOccurred())
+						Expect(IsUnavailable(err)).To(BeTrue())
+					})
+				})
+
 				When("the server endpoint doesn't exist", func() {
 					It("returns an error", func() {
-						_, err := apiConnection.jsonRequest("/this/is/not/a/real/endpoint", "GET", nil)
+						_, err := apiConnection.jsonRequest(context.TODO(), "/this/is/not/a/real/endpoint", "GET", nil)
 						Expect(err).To(HaveOccurred())
 					})
 				})
+
+				When("metrics are recorded", func() {
+					requestCount := func(endpoint string) uint64 {
+						metric := &dto.Metric{}
+						histogram := requestDurations.WithLabelValues(endpoint, "GET").(prometheus.Histogram)
+						Expect(histogram.Write(metric)).To(Succeed())
+						return metric.GetHistogram().GetSampleCount()
+					}
+
+					JustBeforeEach(func() {
+						apiConnection.apiConfig.RecordMetrics = true
+					})
+
+					It("observes the latency of requests, and counts the failed ones", func() {
+						statusRequests := requestCount(SystemStatusEndpoint)
+						unauthorized := requestErrors.WithLabelValues(SystemStatusEndpoint, "GET", "401")
+						unauthorizedErrors := testutil.ToFloat64(unauthorized)
+
+						_, err := apiConnection.fetchSystemStatus(context.TODO())
+						Expect(err).NotTo(HaveOccurred())
+						Expect(requestCount(SystemStatusEndpoint)).To(Equal(statusRequests + 1))
+						Expect(testutil.ToFloat64(unauthorized)).To(Equal(unauthorizedErrors))
+
+						apiConnection.apiConfig.APIKey = "my-super-secret-key-DO-NOT-STEAL!!!"
+						_, err = apiConnection.fetchSystemStatus(context.TODO())
+						Expect(err).To(HaveOccurred())
+						Expect(requestCount(SystemStatusEndpoint)).To(Equal(statusRequests + 2))
+						Expect(testutil.ToFloat64(unauthorized)).To(Equal(unauthorizedErrors + 1))
+					})
+
+					It("labels requests by endpoint, without their query", func() {
+						statusRequests := requestCount(DBStatusEndpoint)
+						_, err := apiConnection.fetchFolderStatus(context.TODO(), "festivus")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(requestCount(DBStatusEndpoint)).To(Equal(statusRequests + 1))
+					})
+
+					It("counts requests which got no response", func() {
+						ts.Close()
+						failed := requestErrors.WithLabelValues(ConfigEndpoint, "GET", noResponseCode)
+						failedErrors := testutil.ToFloat64(failed)
+						_, err := apiConnection.fetchConfig(context.TODO())
+						Expect(err).To(HaveOccurred())
+						Expect(testutil.ToFloat64(failed)).To(Equal(failedErrors + 1))
+					})
+				})
 			})
 		})
 	})
@@ -318,4 +532,113 @@ var _ = Describe("APIConfig", func() {
 			Expect(newClient).NotTo(Equal(client))
 		})
 	})
+
+	When("the certificate served by the API is pinned", func() {
+		var ts *httptest.Server
+		BeforeEach(func() {
+			ts = CreateSyncthingTestServer(&Syncthing{}, "0xDEADBEEF")
+			apiConfig.APIURL = ts.URL
+			apiConfig.APIKey = "0xDEADBEEF"
+		})
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("connects when the certificate matches", func() {
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(ts.Certificate())
+			apiConfig.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: rootCAs}
+			apiConfig.Client = apiConfig.TLSClient()
+
+			_, err := NewConnection(*apiConfig, logr.Discard()).Fetch(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses to connect when the certificate doesn't match", func() {
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(selfSignedCertificate())
+			apiConfig.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: rootCAs}
+			apiConfig.Client = apiConfig.TLSClient()
+
+			_, err := NewConnection(*apiConfig, logr.Discard()).Fetch(context.TODO())
+			Expect(err).To(HaveOccurred())
+			var unknownAuthority x509.UnknownAuthorityError
+			Expect(errors.As(err, &unknownAuthority)).To(BeTrue())
+		})
+
+		It("connects through the Service's DNS name when the certificate's fingerprint matches", func() {
+			// the test server's certificate is issued for example.com & 127.0.0.1, not the Service's name
+			certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+			apiConfig.TLSConfig = PinnedTLSConfig(certPEM, "volsync-festivus-api.seinfeld.svc")
+			apiConfig.Client = apiConfig.TLSClient()
+
+			_, err := NewConnection(*apiConfig, logr.Discard()).Fetch(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("refuses to connect when the pinned fingerprint doesn't match", func() {
+			certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: selfSignedCertificate().Raw})
+			apiConfig.TLSConfig = PinnedTLSConfig(certPEM, "volsync-festivus-api.seinfeld.svc")
+			apiConfig.Client = apiConfig.TLSClient()
+
+			_, err := NewConnection(*apiConfig, logr.Discard()).Fetch(context.TODO())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("doesn't match the pinned certificate"))
+		})
+	})
+
+	When("the API hangs", func() {
+		var ts *httptest.Server
+		var release chan struct{}
+		BeforeEach(func() {
+			release = make(chan struct{})
+			ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+			apiConfig.APIURL = ts.URL
+			apiConfig.APIKey = "0xDEADBEEF"
+			apiConfig.Client = ts.Client()
+		})
+		AfterEach(func() {
+			close(release)
+			ts.Close()
+		})
+
+		It("gives up on the requests once the context is done", func() {
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			connection := NewConnection(*apiConfig, logr.Discard())
+
+			start := time.Now()
+			_, err := connection.Fetch(ctx)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(connection.PublishConfig(ctx, config.Configuration{})).NotTo(Succeed())
+			_, err = connection.FetchConfig(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		})
+	})
 })
+
+// selfSignedCertificate Generates a certificate for 127.0.0.1 which is unrelated to the one served
+// by the test server.
+func selfSignedCertificate() *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "syncthing"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
@@ -17,8 +17,15 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package api
 
 import (
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"net/http"
+	"syscall"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -30,25 +37,34 @@ const (
 	SystemStatusEndpoint      = "/rest/system/status"
 	SystemConnectionsEndpoint = "/rest/system/connections"
 	ConfigEndpoint            = "/rest/config"
+	DBBrowseEndpoint          = "/rest/db/browse"
+	DBStatusEndpoint          = "/rest/db/status"
+	DeviceStatsEndpoint       = "/rest/stats/device"
 )
 
 // Fetch Pulls all of Syncthing's latest information from the API and stores it
 // in the object's local storage.
-func (s *syncthingAPIConnection) Fetch() (*Syncthing, error) {
+func (s *syncthingAPIConnection) Fetch(ctx context.Context) (*Syncthing, error) {
 	// get & store config
-	conf, err := s.fetchConfig()
+	conf, err := s.fetchConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// get & store connection info
-	systemConnections, err := s.fetchSystemConnections()
+	systemConnections, err := s.fetchSystemConnections(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// get and store system status
-	systemStatus, err := s.fetchSystemStatus()
+	systemStatus, err := s.fetchSystemStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// get and store the statistics of each device
+	deviceStats, err := s.fetchDeviceStats(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -57,15 +73,38 @@ func (s *syncthingAPIConnection) Fetch() (*Syncthing, error) {
 		Configuration:     *conf,
 		SystemConnections: *systemConnections,
 		SystemStatus:      *systemStatus,
+		FolderEntries:     map[string][]FileEntry{},
+		FolderStatuses:    map[string]FolderStatus{},
+		DeviceStats:       deviceStats,
 	}, nil
 }
 
+// FetchConfig Pulls only Syncthing's current configuration from the API.
+func (s *syncthingAPIConnection) FetchConfig(ctx context.Context) (*config.Configuration, error) {
+	return s.fetchConfig(ctx)
+}
+
+// FetchFolderEntries Pulls the files and directories that Syncthing tracks within the given folder,
+// down to the given number of levels below the folder's root. Browsing a folder walks Syncthing's index,
+// so this is kept out of Fetch and only used to report the folder's contents.
+func (s *syncthingAPIConnection) FetchFolderEntries(ctx context.Context, folderID string,
+	levels int) ([]FileEntry, error) {
+	return s.fetchFolderEntries(ctx, folderID, levels)
+}
+
+// FetchFolderStatus Pulls the status of the given folder. Like the folder's contents, this is kept out of
+// Fetch and only used to report the folder's status, so that a failing folder doesn't hold off configuring
+// Syncthing.
+func (s *syncthingAPIConnection) FetchFolderStatus(ctx context.Context, folderID string) (*FolderStatus, error) {
+	return s.fetchFolderStatus(ctx, folderID)
+}
+
 // PublishConfig Updates the Syncthing API with the stored configuration data.
 // An error is returned in the case of a failure.
-func (s *syncthingAPIConnection) PublishConfig(conf config.Configuration) error {
+func (s *syncthingAPIConnection) PublishConfig(ctx context.Context, conf config.Configuration) error {
 	// update the config
 	s.logger.Info("Updating Syncthing config")
-	_, err := s.jsonRequest(ConfigEndpoint, "PUT", conf)
+	_, err := s.jsonRequest(ctx, ConfigEndpoint, "PUT", conf)
 	if err != nil {
 		s.logger.Error(err, "Failed to update Syncthing config")
 	}
@@ -106,3 +145,36 @@ func (api APIConfig) TLSClient() *http.Client {
 	}
 	return client
 }
+
+// PinnedTLSConfig Returns a TLS config which only trusts the given PEM-encoded certificates, by comparing
+// their fingerprints with the certificate served by the API. The hostname the certificate was issued for
+// is not verified, so the API can be reached through its Service's DNS name, which is sent as serverName
+// through SNI, even when the certificate was issued for another name.
+func PinnedTLSConfig(certPEM []byte, serverName string) *tls.Config {
+	pinned := map[[sha256.Size]byte]bool{}
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			pinned[sha256.Sum256(block.Bytes)] = true
+		}
+	}
+
+	return &tls.Config{
+		// require at least TLS1.2
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// the certificate is verified against the pinned fingerprints instead
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !pinned[sha256.Sum256(rawCerts[0])] {
+				return fmt.Errorf("the certificate served by the Syncthing API doesn't match the pinned certificate")
+			}
+			return nil
+		},
+	}
+}
+
+// IsUnavailable Determines whether the given error comes from the Syncthing API refusing connections,
+// as it does while Syncthing is starting.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -18,12 +18,16 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/syncthing/syncthing/lib/config"
@@ -45,8 +49,18 @@ func (api *syncthingAPIConnection) headers() map[string]string {
 	}
 }
 
+// endpointURL Returns the URL of the given endpoint, including the configured path prefix if any.
+func (api *syncthingAPIConnection) endpointURL(endpoint string) string {
+	prefix := strings.Trim(api.apiConfig.APIPathPrefix, "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	return api.apiConfig.APIURL + prefix + endpoint
+}
+
 // jsonRequest Makes an HTTPS request to the API at the .
 func (api *syncthingAPIConnection) jsonRequest(
+	ctx context.Context,
 	endpoint string,
 	method string,
 	requestBody interface{},
@@ -59,8 +73,8 @@ func (api *syncthingAPIConnection) jsonRequest(
 	// tostring the json body
 	body := io.Reader(bytes.NewReader(jsonBody))
 
-	// build new client if none exists
-	req, err := http.NewRequest(method, api.apiConfig.APIURL+endpoint, body)
+	// the request is canceled along with the context, so a hung API can't outlive the caller
+	req, err := http.NewRequestWithContext(ctx, method, api.endpointURL(endpoint), body)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +85,11 @@ func (api *syncthingAPIConnection) jsonRequest(
 		req.Header.Set(key, value)
 	}
 
+	start := time.Now()
 	resp, err := api.apiConfig.Client.Do(req)
+	if api.apiConfig.RecordMetrics {
+		recordRequest(endpoint, method, time.Since(start), resp, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -87,10 +105,10 @@ func (api *syncthingAPIConnection) jsonRequest(
 
 // fetchConfig Fetches the latest configuration data from the Syncthing API
 // and uses it to update the local Syncthing object.
-func (api *syncthingAPIConnection) fetchConfig() (*config.Configuration, error) {
+func (api *syncthingAPIConnection) fetchConfig(ctx context.Context) (*config.Configuration, error) {
 	responseBody := &config.Configuration{}
 	api.logger.Info("Fetching Syncthing config")
-	data, err := api.jsonRequest(ConfigEndpoint, "GET", nil)
+	data, err := api.jsonRequest(ctx, ConfigEndpoint, "GET", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -102,10 +120,10 @@ func (api *syncthingAPIConnection) fetchConfig() (*config.Configuration, error)
 
 // fetchSystemStatus Fetches the system status from the Syncthing API,
 // and returns a SystemStatus object on success, or an error on failure.
-func (api *syncthingAPIConnection) fetchSystemStatus() (*SystemStatus, error) {
+func (api *syncthingAPIConnection) fetchSystemStatus(ctx context.Context) (*SystemStatus, error) {
 	responseBody := &SystemStatus{}
 	api.logger.Info("Fetching Syncthing system status")
-	data, err := api.jsonRequest(SystemStatusEndpoint, "GET", nil)
+	data, err := api.jsonRequest(ctx, SystemStatusEndpoint, "GET", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -119,13 +137,64 @@ func (api *syncthingAPIConnection) fetchSystemStatus() (*SystemStatus, error) {
 
 // fetchSystemConnections Fetches information regarding the connections with the running
 // Syncthing node from Syncthing's API. Returns a SystemConnections object if successful, error otherwise.
-func (api *syncthingAPIConnection) fetchSystemConnections() (*SystemConnections, error) {
+func (api *syncthingAPIConnection) fetchSystemConnections(ctx context.Context) (*SystemConnections, error) {
 	// updates the connected status if successful, else returns an error
 	responseBody := &SystemConnections{
 		Connections: map[string]ConnectionStats{},
 	}
 	api.logger.Info("Fetching Syncthing connected status")
-	data, err := api.jsonRequest(SystemConnectionsEndpoint, "GET", nil)
+	data, err := api.jsonRequest(ctx, SystemConnectionsEndpoint, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, responseBody); err != nil {
+		return nil, err
+	}
+	return responseBody, nil
+}
+
+// fetchDeviceStats Fetches the statistics of each device known to Syncthing from the Syncthing API,
+// keyed by the device's ID. Returns the statistics on success, or an error on failure.
+func (api *syncthingAPIConnection) fetchDeviceStats(ctx context.Context) (map[string]DeviceStats, error) {
+	responseBody := map[string]DeviceStats{}
+	api.logger.Info("Fetching Syncthing device statistics")
+	data, err := api.jsonRequest(ctx, DeviceStatsEndpoint, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &responseBody); err != nil {
+		return nil, err
+	}
+	return responseBody, nil
+}
+
+// fetchFolderEntries Fetches the files and directories that Syncthing tracks within the given folder.
+// Returns a list of the top-level entries, each containing their children down to the given number of levels,
+// or an error on failure.
+func (api *syncthingAPIConnection) fetchFolderEntries(ctx context.Context, folderID string,
+	levels int) ([]FileEntry, error) {
+	responseBody := []FileEntry{}
+	api.logger.Info("Fetching Syncthing folder contents", "folder", folderID, "levels", levels)
+	query := url.Values{"folder": {folderID}, "levels": {strconv.Itoa(levels)}}
+	data, err := api.jsonRequest(ctx, DBBrowseEndpoint+"?"+query.Encode(), "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &responseBody); err != nil {
+		return nil, err
+	}
+	return responseBody, nil
+}
+
+// fetchFolderStatus Fetches the status of the given folder from the Syncthing API,
+// and returns a FolderStatus object on success, or an error on failure.
+func (api *syncthingAPIConnection) fetchFolderStatus(ctx context.Context, folderID string) (*FolderStatus, error) {
+	responseBody := &FolderStatus{}
+	api.logger.Info("Fetching Syncthing folder status", "folder", folderID)
+	data, err := api.jsonRequest(ctx, DBStatusEndpoint+"?folder="+url.QueryEscape(folderID), "GET", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// noResponseCode Is reported as the status code of requests which failed without a response.
+const noResponseCode = "none"
+
+var (
+	requestLabels = []string{
+		"endpoint", // REST endpoint of the Syncthing API, without its query
+		"method",   // HTTP method of the request
+	}
+
+	requestDurations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:      "syncthing_api_request_duration_seconds",
+			Namespace: "volsync",
+			Help:      "Duration of the requests made to the Syncthing API in seconds",
+			Buckets:   prometheus.DefBuckets,
+		},
+		requestLabels,
+	)
+	requestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "syncthing_api_request_errors_total",
+			Namespace: "volsync",
+			Help:      "The number of requests to the Syncthing API which failed",
+		},
+		append(requestLabels, "code"),
+	)
+)
+
+func init() {
+	// Register the API metrics with the controller's prometheus registry
+	metrics.Registry.MustRegister(requestDurations, requestErrors)
+}
+
+// recordRequest Records the duration of a request made to the given endpoint, and counts it
+// as an error when it failed or the API responded with an unexpected status code.
+func recordRequest(endpoint string, method string, duration time.Duration, resp *http.Response, err error) {
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+	requestDurations.WithLabelValues(endpoint, method).Observe(duration.Seconds())
+
+	code := noResponseCode
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	if err != nil || resp.StatusCode != http.StatusOK {
+		requestErrors.WithLabelValues(endpoint, method, code).Inc()
+	}
+}
@@ -17,6 +17,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package api
 
 import (
+	"context"
 	"crypto/tls"
 	"net/http"
 
@@ -71,6 +72,38 @@ type SystemConnections struct {
 	Connections map[string]ConnectionStats `json:"connections"`
 }
 
+// FileEntry Describes a file or directory tracked by Syncthing, as returned by the db/browse endpoint.
+type FileEntry struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Size     int64       `json:"size"`
+	ModTime  string      `json:"modTime"`
+	Children []FileEntry `json:"children,omitempty"`
+}
+
+// FolderStatus Describes the state of a folder and how much of it is in sync,
+// as returned by the db/status endpoint.
+type FolderStatus struct {
+	State        string `json:"state"`
+	StateChanged string `json:"stateChanged"`
+	Errors       int    `json:"errors"`
+	GlobalBytes  int64  `json:"globalBytes"`
+	GlobalFiles  int    `json:"globalFiles"`
+	InSyncBytes  int64  `json:"inSyncBytes"`
+	InSyncFiles  int    `json:"inSyncFiles"`
+	LocalBytes   int64  `json:"localBytes"`
+	LocalFiles   int    `json:"localFiles"`
+	NeedBytes    int64  `json:"needBytes"`
+	NeedFiles    int    `json:"needFiles"`
+}
+
+// DeviceStats Describes the statistics Syncthing keeps about a device,
+// as returned by the stats/device endpoint.
+type DeviceStats struct {
+	LastSeen                string  `json:"lastSeen"`
+	LastConnectionDurationS float64 `json:"lastConnectionDurationS"`
+}
+
 // APIConfig Describes the necessary elements needed to configure a client
 // with the Syncthing API, included the credentials, URL, TLS Certs.
 // This requires nolint:revive because the package it's in is called "api,"
@@ -80,6 +113,11 @@ type SystemConnections struct {
 type APIConfig struct {
 	APIURL string `json:"apiURL"`
 	APIKey string `json:"apiKey"`
+	// APIPathPrefix Is prepended to the path of every REST endpoint, for when
+	// the API is served under a path prefix, e.g. by a reverse-proxy.
+	APIPathPrefix string `json:"apiPathPrefix"`
+	// RecordMetrics Enables the metrics of the latency and errors of the requests made to the API.
+	RecordMetrics bool `json:"recordMetrics"`
 	// don't marshal this field
 	TLSConfig *tls.Config
 	Client    *http.Client
@@ -87,15 +125,27 @@ type APIConfig struct {
 
 type SyncthingConnection interface {
 	// API Functions, these are meant to define communication with the Syncthing API.
-	Fetch() (*Syncthing, error)
-	PublishConfig(config.Configuration) error
+	// The requests are canceled along with the given context.
+	Fetch(context.Context) (*Syncthing, error)
+	FetchConfig(context.Context) (*config.Configuration, error)
+	FetchFolderEntries(ctx context.Context, folderID string, levels int) ([]FileEntry, error)
+	FetchFolderStatus(ctx context.Context, folderID string) (*FolderStatus, error)
+	PublishConfig(context.Context, config.Configuration) error
 }
 
 // Syncthing Defines a Syncthing API object which contains a subset of the information
 // exposed through Syncthing's API. Namely, this struct exposes the configuration,
-// system status, and connections contained by the given object.
+// system status, connections, folder contents & statuses, and device statistics contained by the given object.
 type Syncthing struct {
 	Configuration     config.Configuration
 	SystemConnections SystemConnections
 	SystemStatus      SystemStatus
+	// FolderEntries Holds the contents of each folder, keyed by the folder's ID. These aren't pulled by
+	// Fetch, and folders whose contents couldn't be pulled are missing.
+	FolderEntries map[string][]FileEntry
+	// FolderStatuses Holds the status of each folder, keyed by the folder's ID. These aren't pulled by
+	// Fetch, and folders whose status couldn't be pulled are missing.
+	FolderStatuses map[string]FolderStatus
+	// DeviceStats Holds the statistics of each device, keyed by the device's ID.
+	DeviceStats map[string]DeviceStats
 }
@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
 )
 
 // GetDeviceFromID Returns a pointer to the device with the given ID,
@@ -42,20 +44,25 @@ func (s *Syncthing) MyID() string { return s.SystemStatus.MyID }
 // ShareFoldersWithDevices Will set all of the given devices to be shared with the
 // currently tracked folders.
 //
-// This method does not currently take into account any encryption password set
-// on the folder by the device.
+// The encryption passwords set on the folders for devices which keep sharing them are preserved.
 func (s *Syncthing) ShareFoldersWithDevices(devices []config.DeviceConfiguration) {
 	// share the current folder(s) with the new devices
 	var newFolders = []config.FolderConfiguration{}
 	for _, folder := range s.Configuration.Folders {
+		passwords := map[protocol.DeviceID]string{}
+		for _, device := range folder.Devices {
+			passwords[device.DeviceID] = device.EncryptionPassword
+		}
+
 		// copy folder & reset
 		newFolder := folder
 		newFolder.Devices = []config.FolderDeviceConfiguration{}
 
 		for _, device := range s.Configuration.Devices {
 			newFolder.Devices = append(newFolder.Devices, config.FolderDeviceConfiguration{
-				DeviceID:     device.DeviceID,
-				IntroducedBy: device.IntroducedBy,
+				DeviceID:           device.DeviceID,
+				IntroducedBy:       device.IntroducedBy,
+				EncryptionPassword: passwords[device.DeviceID],
 			})
 		}
 		newFolders = append(newFolders, newFolder)
@@ -64,7 +71,7 @@ func (s *Syncthing) ShareFoldersWithDevices(devices []config.DeviceConfiguration
 }
 
 // CreateSyncthingTestServer Returns a test server that mimics the Syncthing API by exposing
-// the endpoints for config, system status, and system connections.
+// the endpoints for config, system status, system connections, folder contents & statuses, and device statistics.
 // The server also accepts an API Key, which is used for authenticating between the client and server.
 //
 // The accepted arguments are pointers so that the state can be changed externally and the server
@@ -117,6 +124,30 @@ func CreateSyncthingTestServer(state *Syncthing, serverAPIKey string) *httptest.
 			resBytes, _ := json.Marshal(res)
 			fmt.Fprintln(w, string(resBytes))
 			return
+		case DBBrowseEndpoint:
+			res := state.FolderEntries[r.URL.Query().Get("folder")]
+			if res == nil {
+				res = []FileEntry{}
+			}
+			if levels, err := strconv.Atoi(r.URL.Query().Get("levels")); err == nil {
+				res = truncateEntries(res, levels)
+			}
+			resBytes, _ := json.Marshal(res)
+			fmt.Fprintln(w, string(resBytes))
+			return
+		case DBStatusEndpoint:
+			res := state.FolderStatuses[r.URL.Query().Get("folder")]
+			resBytes, _ := json.Marshal(res)
+			fmt.Fprintln(w, string(resBytes))
+			return
+		case DeviceStatsEndpoint:
+			res := state.DeviceStats
+			if res == nil {
+				res = map[string]DeviceStats{}
+			}
+			resBytes, _ := json.Marshal(res)
+			fmt.Fprintln(w, string(resBytes))
+			return
 		default:
 			// the endpoint doesn't exist
 			http.Error(w, "the resource path doesn't exist", http.StatusNotFound)
@@ -124,3 +155,18 @@ func CreateSyncthingTestServer(state *Syncthing, serverAPIKey string) *httptest.
 		}
 	}))
 }
+
+// truncateEntries Returns a copy of the given entries which only keeps the children down to the given
+// number of levels, as Syncthing does when browsing a folder.
+func truncateEntries(entries []FileEntry, levels int) []FileEntry {
+	truncated := make([]FileEntry, len(entries))
+	for i, entry := range entries {
+		if levels > 0 {
+			entry.Children = truncateEntries(entry.Children, levels-1)
+		} else {
+			entry.Children = nil
+		}
+		truncated[i] = entry
+	}
+	return truncated
+}
@@ -20,11 +20,17 @@ package syncthing
 import (
 	"flag"
 	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/viper"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
@@ -46,6 +52,10 @@ const (
 	defaultSyncthingContainerImage = "quay.io/backube/volsync:latest"
 	syncthingContainerImageFlag    = "syncthing-container-image"
 	syncthingContainerImageEnvVar  = "RELATED_IMAGE_SYNCTHING_CONTAINER"
+	syncthingAPIMetricsFlag        = "syncthing-api-metrics"
+	syncthingControllerNSFlag      = "syncthing-controller-namespace"
+	// the namespace of the service account VolSync runs as, when running in a cluster
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 )
 
 // Register Creates a builder for the Syncthing mover package and registers it as
@@ -76,9 +86,29 @@ func newBuilder(viper *viper.Viper, flags *flag.FlagSet) (*Builder, error) {
 	// Viper will check for command line flag first, then fallback to the env var
 	err := b.viper.BindEnv(syncthingContainerImageFlag, syncthingContainerImageEnvVar)
 
+	// Setup command line flag enabling the metrics of the requests made to the Syncthing API
+	b.flags.Bool(syncthingAPIMetricsFlag, false,
+		"Whether to export metrics of the latency and errors of requests to the Syncthing API")
+
+	// Setup command line flag for the namespace VolSync reaches the API from, when it's local to the mover's pod
+	controllerNamespace := getInClusterNamespace()
+	b.viper.SetDefault(syncthingControllerNSFlag, controllerNamespace)
+	b.flags.String(syncthingControllerNSFlag, controllerNamespace,
+		"The namespace VolSync runs in, the only one allowed to reach the API of Syncthing movers with podLocalAPI")
+
 	return b, err
 }
 
+// getInClusterNamespace Returns the namespace VolSync runs in, or an empty string when it isn't running in a
+// cluster.
+func getInClusterNamespace() string {
+	namespace, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(namespace))
+}
+
 // VersionInfo Returns the Syncthing container image version being used by this Builder.
 func (rb *Builder) VersionInfo() string {
 	return fmt.Sprintf("Syncthing container: %s", rb.getSyncthingContainerImage())
@@ -90,6 +120,8 @@ func (rb *Builder) getSyncthingContainerImage() string {
 }
 
 // FromSource Builds a Syncthing mover object from a given ReplicationSource object.
+//
+//nolint:funlen
 func (rb *Builder) FromSource(client client.Client, logger logr.Logger,
 	eventRecorder events.EventRecorder,
 	source *volsyncv1alpha1.ReplicationSource, privileged bool) (mover.Mover, error) {
@@ -111,34 +143,212 @@ func (rb *Builder) FromSource(client client.Client, logger logr.Logger,
 		serviceType = corev1.ServiceTypeClusterIP
 	}
 
+	workloadType := volsyncv1alpha1.SyncthingWorkloadDeployment
+	if source.Spec.Syncthing.WorkloadType != nil {
+		workloadType = *source.Spec.Syncthing.WorkloadType
+	}
+
+	// image from the spec, or the one the controller was configured with
+	if err := validateMoverImage(source.Spec.Syncthing.MoverImage, privileged); err != nil {
+		return nil, err
+	}
+	containerImage := rb.getSyncthingContainerImage()
+	if source.Spec.Syncthing.MoverImage != nil && *source.Spec.Syncthing.MoverImage != "" {
+		containerImage = *source.Spec.Syncthing.MoverImage
+	}
+
+	terminationMessagePolicy := corev1.TerminationMessageFallbackToLogsOnError
+	if source.Spec.Syncthing.TerminationMessagePolicy != nil {
+		terminationMessagePolicy = *source.Spec.Syncthing.TerminationMessagePolicy
+	}
+
+	apiKeySecretKey := apiKeyDataKey
+	if source.Spec.Syncthing.APIKeySecretKey != nil {
+		apiKeySecretKey = *source.Spec.Syncthing.APIKeySecretKey
+	}
+	if err := validateAPIKeySecretKey(apiKeySecretKey); err != nil {
+		return nil, err
+	}
+	if err := validateSyncInterval(source.Spec.Syncthing.SyncInterval); err != nil {
+		return nil, err
+	}
+	if err := validateStatsSnapshots(source.Spec.Syncthing.StatsSnapshots); err != nil {
+		return nil, err
+	}
+	if err := validateIndexSnapshots(source.Spec.Syncthing.IndexSnapshots); err != nil {
+		return nil, err
+	}
+	if err := validateAPIExposure(source.Spec.Syncthing.ExposeAPI, source.Spec.Syncthing.PodLocalAPI); err != nil {
+		return nil, err
+	}
+
+	// volume names or defaults
+	configVolumeName := defaultConfigVolumeName
+	if source.Spec.Syncthing.ConfigVolumeName != nil {
+		configVolumeName = *source.Spec.Syncthing.ConfigVolumeName
+	}
+	dataVolumeName := defaultDataVolumeName
+	if source.Spec.Syncthing.DataVolumeName != nil {
+		dataVolumeName = *source.Spec.Syncthing.DataVolumeName
+	}
+	if err := validateVolumeNames(configVolumeName, dataVolumeName, certVolumeName); err != nil {
+		return nil, err
+	}
+
 	saHandler := utils.NewSAHandler(client, source, true, privileged,
 		source.Spec.Syncthing.MoverServiceAccount)
 
 	syncthingLogger := logger.WithValues("method", "Syncthing")
 
 	return &Mover{
-		client:               client,
-		logger:               syncthingLogger,
-		owner:                source,
-		saHandler:            saHandler,
-		eventRecorder:        eventRecorder,
-		configCapacity:       source.Spec.Syncthing.ConfigCapacity,
-		configStorageClass:   source.Spec.Syncthing.ConfigStorageClassName,
-		configAccessModes:    source.Spec.Syncthing.ConfigAccessModes,
-		containerImage:       rb.getSyncthingContainerImage(),
-		peerList:             source.Spec.Syncthing.Peers,
-		paused:               source.Spec.Paused,
-		dataPVCName:          &source.Spec.SourcePVC,
-		status:               source.Status.Syncthing,
-		serviceType:          serviceType,
-		syncthingConnection:  nil,
-		apiConfig:            api.APIConfig{},
-		privileged:           privileged,
-		moverSecurityContext: source.Spec.Syncthing.MoverSecurityContext,
+		client:                   client,
+		logger:                   syncthingLogger,
+		owner:                    source,
+		saHandler:                saHandler,
+		eventRecorder:            eventRecorder,
+		configCapacity:           source.Spec.Syncthing.ConfigCapacity,
+		maxIndexSize:             source.Spec.Syncthing.MaxIndexSize,
+		autoSizeConfig:           source.Spec.Syncthing.AutoSizeConfig,
+		renderConfig:             source.Spec.Syncthing.RenderConfig,
+		configStorageClass:       source.Spec.Syncthing.ConfigStorageClassName,
+		configAccessModes:        source.Spec.Syncthing.ConfigAccessModes,
+		containerImage:           containerImage,
+		imagePullPolicy:          source.Spec.Syncthing.ImagePullPolicy,
+		moverResources:           source.Spec.Syncthing.MoverResources,
+		peerList:                 source.Spec.Syncthing.Peers,
+		paused:                   source.Spec.Paused,
+		dataPVCName:              &source.Spec.SourcePVC,
+		status:                   source.Status.Syncthing,
+		conditions:               &source.Status.Conditions,
+		serviceType:              serviceType,
+		syncthingConnection:      nil,
+		apiConfig:                api.APIConfig{RecordMetrics: rb.viper.GetBool(syncthingAPIMetricsFlag)},
+		privileged:               privileged,
+		moverSecurityContext:     source.Spec.Syncthing.MoverSecurityContext,
+		advertisedAddress:        source.Spec.Syncthing.AdvertisedAddress,
+		localDeviceName:          source.Spec.Syncthing.LocalDeviceName,
+		annotateAddress:          source.Spec.Syncthing.AnnotateAddress,
+		annotateOwnerUID:         source.Spec.Syncthing.AnnotateOwnerUID,
+		folder:                   source.Spec.Syncthing.Folder,
+		maxPeers:                 source.Spec.Syncthing.MaxPeers,
+		exposeAPI:                source.Spec.Syncthing.ExposeAPI,
+		podLocalAPI:              source.Spec.Syncthing.PodLocalAPI,
+		controllerNamespace:      rb.viper.GetString(syncthingControllerNSFlag),
+		dataNodePort:             source.Spec.Syncthing.DataNodePort,
+		apiNodePort:              source.Spec.Syncthing.APINodePort,
+		useHostPort:              source.Spec.Syncthing.UseHostPort,
+		allowedDataSources:       source.Spec.Syncthing.AllowedDataSources,
+		startupHealthTimeout:     source.Spec.Syncthing.StartupHealthTimeoutSeconds,
+		healthCheckPath:          source.Spec.Syncthing.HealthCheckPath,
+		options:                  source.Spec.Syncthing.Options,
+		schedulerName:            source.Spec.Syncthing.SchedulerName,
+		enableServiceLinks:       source.Spec.Syncthing.EnableServiceLinks,
+		configVolumeName:         configVolumeName,
+		dataVolumeName:           dataVolumeName,
+		apiCertSecretName:        source.Spec.Syncthing.APICertificateSecret,
+		apiPathPrefix:            source.Spec.Syncthing.APIPathPrefix,
+		encryptionSecretName:     source.Spec.Syncthing.EncryptionPasswordSecret,
+		terminationMessagePolicy: terminationMessagePolicy,
+		debug:                    source.Spec.Syncthing.Debug,
+		manageFolders:            source.Spec.Syncthing.ManageFolders == nil || *source.Spec.Syncthing.ManageFolders,
+		workloadType:             workloadType,
+		stalePeerThreshold:       source.Spec.Syncthing.StalePeerThreshold,
+		checkPeerReachability:    source.Spec.Syncthing.CheckPeerReachability,
+		dialer:                   &net.Dialer{Timeout: peerDialTimeout},
+		sysctls:                  source.Spec.Syncthing.Sysctls,
+		apiKeyRotationInterval:   source.Spec.Syncthing.APIKeyRotationInterval,
+		apiKeySecretKey:          apiKeySecretKey,
+		synchronizeTimeout:       source.Spec.Syncthing.SynchronizeTimeout,
+		syncInterval:             source.Spec.Syncthing.SyncInterval,
+		statsSnapshots:           source.Spec.Syncthing.StatsSnapshots,
+		indexSnapshots:           source.Spec.Syncthing.IndexSnapshots,
+		clock:                    clock.RealClock{},
 		// defer setting the VolumeHandler
 	}, nil
 }
 
+// validateVolumeNames Returns an error if any of the given volume names are used more than once,
+// as the volumes in the mover Pod must have unique names.
+func validateVolumeNames(volumeNames ...string) error {
+	seen := map[string]bool{}
+	for _, name := range volumeNames {
+		if seen[name] {
+			return fmt.Errorf("volume name %q is used by more than one volume", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateAPIKeySecretKey Returns an error if the API key would overwrite any of the other values
+// in the API secret when stored under the given key.
+func validateAPIKeySecretKey(key string) error {
+	switch key {
+	case usernameDataKey, passwordDataKey, httpsCertDataKey, httpsKeyDataKey:
+		return fmt.Errorf("apiKeySecretKey %q is already used by the API secret", key)
+	}
+	return nil
+}
+
+// validateSyncInterval Returns an error if the given interval between synchronization passes isn't positive.
+func validateSyncInterval(interval *metav1.Duration) error {
+	if interval != nil && interval.Duration <= 0 {
+		return fmt.Errorf("syncInterval must be positive, got %s", interval.Duration)
+	}
+	return nil
+}
+
+// validateStatsSnapshots Returns an error if the statistics would be snapshotted more often than allowed.
+func validateStatsSnapshots(statsSnapshots *volsyncv1alpha1.SyncthingStatsSnapshotsSpec) error {
+	if statsSnapshots != nil && statsSnapshots.Interval != nil &&
+		statsSnapshots.Interval.Duration < minStatsSnapshotInterval {
+		return fmt.Errorf("statsSnapshots.interval must be at least %s, got %s",
+			minStatsSnapshotInterval, statsSnapshots.Interval.Duration)
+	}
+	return nil
+}
+
+// validateIndexSnapshots Returns an error if the index would be snapshotted more often than allowed.
+func validateIndexSnapshots(indexSnapshots *volsyncv1alpha1.SyncthingIndexSnapshotsSpec) error {
+	if indexSnapshots != nil && indexSnapshots.Interval != nil &&
+		indexSnapshots.Interval.Duration < minIndexSnapshotInterval {
+		return fmt.Errorf("indexSnapshots.interval must be at least %s, got %s",
+			minIndexSnapshotInterval, indexSnapshots.Interval.Duration)
+	}
+	return nil
+}
+
+// validateAPIExposure Returns an error if the API is both exposed and kept local to the mover's pod.
+func validateAPIExposure(exposeAPI bool, podLocalAPI bool) error {
+	if exposeAPI && podLocalAPI {
+		return fmt.Errorf("exposeAPI and podLocalAPI can't be set together")
+	}
+	return nil
+}
+
+// imageReferenceRegexp Matches a container image reference: an optional registry host and port, the
+// repository path, an optional tag and an optional digest, e.g. registry.example.com:5000/volsync:v1.
+var imageReferenceRegexp = regexp.MustCompile(`^` +
+	`(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+// validateMoverImage Returns an error if the image overriding the mover's image isn't a valid reference,
+// or if the mover is privileged, as any image could then be run with elevated permissions.
+func validateMoverImage(image *string, privileged bool) error {
+	if image == nil || *image == "" {
+		return nil
+	}
+	if !imageReferenceRegexp.MatchString(*image) {
+		return fmt.Errorf("moverImage %q is not a valid image reference", *image)
+	}
+	if privileged {
+		return fmt.Errorf("moverImage can't be set while the mover is privileged")
+	}
+	return nil
+}
+
 // FromDestination Doesn't implement Syncthing, so nil is returned in both cases.
 func (rb *Builder) FromDestination(client client.Client, logger logr.Logger,
 	eventRecorder events.EventRecorder,
@@ -19,20 +19,33 @@ package syncthing
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"github.com/syncthing/syncthing/lib/config"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -47,10 +60,12 @@ import (
 
 // Environment variables used by the Syncthing image.
 const (
-	dataDirEnv   = "SYNCTHING_DATA_DIR"
-	configDirEnv = "SYNCTHING_CONFIG_DIR"
-	certDirEnv   = "SYNCTHING_CERT_DIR"
-	apiKeyEnv    = "STGUIAPIKEY"
+	dataDirEnv       = "SYNCTHING_DATA_DIR"
+	configDirEnv     = "SYNCTHING_CONFIG_DIR"
+	certDirEnv       = "SYNCTHING_CERT_DIR"
+	apiKeyEnv        = "STGUIAPIKEY"
+	healthTimeoutEnv = "SYNCTHING_HEALTH_TIMEOUT"
+	healthPathEnv    = "SYNCTHING_HEALTH_PATH"
 )
 
 // Directories where files will be loaded into the Syncthing container.
@@ -62,9 +77,9 @@ const (
 
 // Volume names loaded by the Deployment.
 const (
-	certVolumeName   = "https-certs"
-	configVolumeName = "syncthing-config"
-	dataVolumeName   = "syncthing-data"
+	certVolumeName          = "https-certs"
+	defaultConfigVolumeName = "syncthing-config"
+	defaultDataVolumeName   = "syncthing-data"
 )
 
 // Ports used by the Syncthing container.
@@ -82,8 +97,15 @@ const (
 	apiKeyDataKey    = "apikey"
 	usernameDataKey  = "username"
 	passwordDataKey  = "password"
+	caCertDataKey    = "ca.crt"
 )
 
+// renderedConfigDataKey Is the key holding the rendered Syncthing config in its ConfigMap.
+const renderedConfigDataKey = "config.json"
+
+// statsSnapshotDataKey Is the key holding the statistics in the ConfigMap of each snapshot.
+const statsSnapshotDataKey = "stats.json"
+
 // Filepaths for where the HTTPS certificate and key will be
 // saved after being loaded into the container.
 const (
@@ -95,30 +117,160 @@ const (
 const (
 	// configCapacity Sets the size of the config volume used by the Syncthing container.
 	configCapacity = "1Gi"
+	// configCapacityPerFolder Is added to the size of the config volume for every additional folder
+	// when it is sized automatically.
+	configCapacityPerFolder = "512Mi"
+	// indexBytesPerFile Is the approximate size of the metadata Syncthing's index keeps for each file.
+	indexBytesPerFile = 512
+	// indexBytesPerBlock Is the approximate size of the hash and offsets kept in the index for each block
+	// of a file.
+	indexBytesPerBlock = 64
+	// indexBlockSize Is the smallest block size used by Syncthing. Larger files use larger blocks, so
+	// estimates based on it err on the high side.
+	indexBlockSize = 128 << 10
 	// resourcePrefix Prefixes every name for resources created by the VolSync controller.
 	resourcePrefix = "volsync-"
+	// managedFolderID Is the ID of the folder holding the data, as set in the mover's config template.
+	managedFolderID = "syncthing-folder-id"
+	// defaultFolderLabel Is the label given to the Syncthing folder when none is specified.
+	defaultFolderLabel = "synced volume"
+	// defaultFolderMarkerName Is the folder marker used by Syncthing when none is specified.
+	defaultFolderMarkerName = ".stfolder"
+	// defaultFolderMaxConcurrentWrites Is the number of concurrent writes Syncthing allows per folder by default.
+	defaultFolderMaxConcurrentWrites = 2
+	// apiKeyHashAnnotation Holds a hash of the API key on the mover's pod template.
+	apiKeyHashAnnotation = "volsync.backube/apikey-hash"
+	// apiCertHashAnnotation Holds a hash of the user-provided API certificate on the mover's pod template.
+	apiCertHashAnnotation = "volsync.backube/api-cert-hash"
+	// addressAnnotation Mirrors the address reported in the status on the ReplicationSource when requested.
+	addressAnnotation = "volsync.backube/syncthing-address"
+	// pausedAnnotation Stops the mover from being reconciled while it's set on the ReplicationSource,
+	// whatever its value.
+	pausedAnnotation = "volsync.backube/syncthing-paused"
+	// ownerUIDAnnotation Holds the UID of the ReplicationSource on the objects created for the mover when requested.
+	ownerUIDAnnotation = "volsync.backube/owner-uid"
+	// apiKeyRotatedAtAnnotation Records on the API key's secret when the key was last rotated.
+	apiKeyRotatedAtAnnotation = "volsync.backube/apikey-rotated-at"
+	// statsSnapshotLabel Holds the UID of the ReplicationSource on the ConfigMaps of its stats snapshots.
+	statsSnapshotLabel = "volsync.backube/syncthing-stats"
+	// statsTakenAtAnnotation Records on the ConfigMap of a stats snapshot when it was taken.
+	statsTakenAtAnnotation = "volsync.backube/stats-taken-at"
+	// defaultStatsSnapshotInterval Is how often stats snapshots are taken when no interval is specified.
+	defaultStatsSnapshotInterval = time.Hour
+	// defaultStatsSnapshotRetain Is the number of stats snapshots retained when no count is specified.
+	defaultStatsSnapshotRetain = 24
+	// minStatsSnapshotInterval Is the shortest interval allowed between snapshots of the statistics.
+	minStatsSnapshotInterval = time.Minute
+	// indexSnapshotLabel Holds the UID of the ReplicationSource on the VolumeSnapshots of its index.
+	indexSnapshotLabel = "volsync.backube/syncthing-index"
+	// indexTakenAtAnnotation Records on the VolumeSnapshot of the index when it was taken.
+	indexTakenAtAnnotation = "volsync.backube/index-taken-at"
+	// defaultIndexSnapshotInterval Is how often the index is snapshotted when no interval is specified.
+	defaultIndexSnapshotInterval = 24 * time.Hour
+	// defaultIndexSnapshotRetain Is the number of index snapshots retained when no count is specified.
+	defaultIndexSnapshotRetain = 3
+	// minIndexSnapshotInterval Is the shortest interval allowed between snapshots of the index.
+	minIndexSnapshotInterval = time.Hour
+	// redactedValue Replaces credentials in the rendered Syncthing config.
+	redactedValue = "REDACTED"
+	// synchronizeInterval Is how long VolSync waits between synchronization passes when no interval
+	// is specified.
+	synchronizeInterval = 20 * time.Second
+	// how long to hold off on updating Syncthing's config once it has failed to persist it
+	configNotPersistingRetryInterval = 5 * time.Minute
+	// apiUnavailableRetryInterval Is how long VolSync waits before retrying while the Syncthing API
+	// isn't available yet, e.g. while Syncthing is starting.
+	apiUnavailableRetryInterval = 5 * time.Second
+	// defaultHealthCheckPath Is the API endpoint reporting whether Syncthing is healthy, unless another
+	// one is specified.
+	defaultHealthCheckPath = "/rest/noauth/health"
+	// defaultSynchronizeTimeout Bounds a synchronization pass when no timeout is specified.
+	defaultSynchronizeTimeout = 2 * time.Minute
+	// maxConflictsReported Limits the number of conflicting files listed in the status of each folder.
+	maxConflictsReported = 10
+	// folderBrowseLevels Bounds how many levels below each folder's root are looked through for conflicts,
+	// so that the whole tree isn't walked on every reconcile.
+	folderBrowseLevels = 3
+	// peerDialTimeout Bounds each attempt at dialing a peer when checking whether it's reachable.
+	peerDialTimeout = 3 * time.Second
+	// peerReachabilityTimeout Bounds the check of all the peers, which are dialed concurrently, so that
+	// it delays the reconcile by at most this long.
+	peerReachabilityTimeout = 5 * time.Second
 )
 
+// peerDialer Dials the addresses of peers to check whether they're reachable, as done by net.Dialer.
+type peerDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
 // Mover is the reconciliation logic for the Restic-based data mover.
 type Mover struct {
-	client               client.Client
-	logger               logr.Logger
-	owner                client.Object
-	saHandler            utils.SAHandler
-	eventRecorder        events.EventRecorder
-	configCapacity       *resource.Quantity
-	configStorageClass   *string
-	configAccessModes    []corev1.PersistentVolumeAccessMode
-	containerImage       string
-	paused               bool
-	dataPVCName          *string
-	peerList             []volsyncv1alpha1.SyncthingPeer
-	status               *volsyncv1alpha1.ReplicationSourceSyncthingStatus
-	serviceType          corev1.ServiceType
-	syncthingConnection  api.SyncthingConnection
-	apiConfig            api.APIConfig
-	privileged           bool
-	moverSecurityContext *corev1.PodSecurityContext
+	client                   client.Client
+	logger                   logr.Logger
+	owner                    client.Object
+	saHandler                utils.SAHandler
+	eventRecorder            events.EventRecorder
+	configCapacity           *resource.Quantity
+	maxIndexSize             *resource.Quantity
+	autoSizeConfig           bool
+	renderConfig             bool
+	configStorageClass       *string
+	configAccessModes        []corev1.PersistentVolumeAccessMode
+	containerImage           string
+	imagePullPolicy          *corev1.PullPolicy
+	moverResources           *corev1.ResourceRequirements
+	paused                   bool
+	dataPVCName              *string
+	peerList                 []volsyncv1alpha1.SyncthingPeer
+	status                   *volsyncv1alpha1.ReplicationSourceSyncthingStatus
+	conditions               *[]metav1.Condition
+	serviceType              corev1.ServiceType
+	syncthingConnection      api.SyncthingConnection
+	apiConfig                api.APIConfig
+	privileged               bool
+	moverSecurityContext     *corev1.PodSecurityContext
+	advertisedAddress        *string
+	localDeviceName          *string
+	annotateAddress          bool
+	annotateOwnerUID         bool
+	folder                   *volsyncv1alpha1.SyncthingFolderSpec
+	maxPeers                 *int32
+	exposeAPI                bool
+	podLocalAPI              bool
+	controllerNamespace      string
+	dataNodePort             *int32
+	apiNodePort              *int32
+	useHostPort              bool
+	allowedDataSources       []networkingv1.NetworkPolicyPeer
+	hostNode                 *corev1.Node
+	clock                    clock.PassiveClock
+	startupHealthTimeout     *int32
+	healthCheckPath          *string
+	options                  *volsyncv1alpha1.SyncthingOptionsSpec
+	schedulerName            *string
+	enableServiceLinks       *bool
+	configVolumeName         string
+	dataVolumeName           string
+	apiCertSecretName        *string
+	apiPathPrefix            *string
+	apiCertPEM               []byte
+	apiCertHash              string
+	encryptionSecretName     *string
+	encryptionPasswords      map[string]string
+	terminationMessagePolicy corev1.TerminationMessagePolicy
+	debug                    *volsyncv1alpha1.SyncthingDebugSpec
+	manageFolders            bool
+	workloadType             volsyncv1alpha1.SyncthingWorkloadType
+	stalePeerThreshold       *metav1.Duration
+	checkPeerReachability    bool
+	dialer                   peerDialer
+	apiKeyRotationInterval   *metav1.Duration
+	apiKeySecretKey          string
+	synchronizeTimeout       *metav1.Duration
+	syncInterval             *metav1.Duration
+	statsSnapshots           *volsyncv1alpha1.SyncthingStatsSnapshotsSpec
+	indexSnapshots           *volsyncv1alpha1.SyncthingIndexSnapshotsSpec
+	sysctls                  []corev1.Sysctl
 }
 
 var _ mover.Mover = &Mover{}
@@ -145,17 +297,61 @@ func (m *Mover) Name() string { return "syncthing" }
 //
 // Synchronize also updates the ReplicationSource's status
 // with information about our local Syncthing instance, as well
-// as any connections that have been made to the Syncthing instance.
+// as any connections that have been made to the Syncthing instance,
+// and whether the mover has reached a steady state.
 func (m *Mover) Synchronize(ctx context.Context) (mover.Result, error) {
+	// leave the mover's resources and Syncthing's config untouched while the ReplicationSource is paused
+	if m.paused {
+		m.logger.V(4).Info("the ReplicationSource is paused, skipping the synchronization pass")
+		return mover.RetryAfter(m.getSyncInterval()), nil
+	}
+	// the annotation lets the mover be frozen, e.g. for an investigation, without editing the spec
+	if _, found := m.owner.GetAnnotations()[pausedAnnotation]; found {
+		m.logger.V(4).Info("reconciling is paused through an annotation, skipping the synchronization pass",
+			"annotation", pausedAnnotation)
+		return mover.RetryAfter(m.getSyncInterval()), nil
+	}
+
+	// bound the whole pass, so a single stuck step can't hold the reconcile forever
+	passCtx, cancel := context.WithTimeout(ctx, m.getSynchronizeTimeout())
+	defer cancel()
+	result, err := m.synchronize(passCtx)
+	if err != nil && passCtx.Err() != nil && ctx.Err() == nil {
+		m.logger.Info("the synchronization pass timed out, retrying", "error", err.Error())
+		apimeta.SetStatusCondition(m.conditions, metav1.Condition{
+			Type:    volsyncv1alpha1.ConditionSynchronizeTimedOut,
+			Status:  metav1.ConditionTrue,
+			Reason:  volsyncv1alpha1.SynchronizeTimedOutReasonDeadline,
+			Message: fmt.Sprintf("the synchronization pass did not complete within %s", m.getSynchronizeTimeout()),
+		})
+		return mover.InProgress(), nil
+	}
+	apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionSynchronizeTimedOut)
+	return result, err
+}
+
+// synchronize Runs a single synchronization pass, bringing the mover's resources and
+// Syncthing's config in line with the spec and updating the status.
+func (m *Mover) synchronize(ctx context.Context) (mover.Result, error) {
+	// the mover is only ready once a synchronization cycle has fully completed
+	m.status.Ready = false
 	dataService, secretAPIKey, err := m.ensureNecessaryResources(ctx)
+	if dataService == nil || err != nil {
+		return mover.InProgress(), err
+	}
+	syncthingState, err := m.interactWithSyncthing(ctx, dataService, secretAPIKey)
+	if api.IsUnavailable(err) {
+		// Syncthing takes a few seconds to serve its API once the mover's pod has started
+		m.logger.V(1).Info("the Syncthing API isn't available yet, retrying", "error", err.Error())
+		return mover.RetryAfter(apiUnavailableRetryInterval), nil
+	}
 	if err != nil {
 		return mover.InProgress(), err
 	}
-	if err = m.interactWithSyncthing(dataService, secretAPIKey); err != nil {
+	if m.status.Ready, err = m.isReady(ctx, syncthingState); err != nil {
 		return mover.InProgress(), err
 	}
-	var retryAfter = 20 * time.Second
-	return mover.RetryAfter(retryAfter), nil
+	return mover.RetryAfter(m.getSyncInterval()), nil
 }
 
 // ensureNecessaryResources Creates the resources required for VolSync to operate the Syncthing mover,
@@ -179,76 +375,265 @@ func (m *Mover) ensureNecessaryResources(ctx context.Context) (*corev1.Service,
 		return nil, nil, err
 	}
 
+	// neither of these is kept in sync through a CreateOrUpdate, so their annotation is patched
+	for _, obj := range []client.Object{configPVC, secretAPIKey} {
+		if err = m.patchOwnerUIDAnnotation(ctx, obj); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err = m.ensureAPICertificate(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	if err = m.ensureEncryptionPasswords(ctx); err != nil {
+		return nil, nil, err
+	}
+
 	sa, err := m.saHandler.Reconcile(ctx, m.logger)
 	if sa == nil || err != nil {
 		return nil, nil, err
 	}
 
-	deployment, err := m.ensureDeployment(ctx, dataPVC, configPVC, sa, secretAPIKey)
-	if deployment == nil || err != nil {
+	if err = m.validateSysctls(); err != nil {
 		return nil, nil, err
 	}
 
-	APIService, err := m.ensureAPIService(ctx, deployment)
-	if APIService == nil || err != nil {
+	if m.useHostPort || m.serviceType == corev1.ServiceTypeNodePort {
+		if err = m.ensureHostNode(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	podTemplate, err := m.ensureWorkload(ctx, dataPVC, configPVC, sa, secretAPIKey)
+	if podTemplate == nil || err != nil {
 		return nil, nil, err
 	}
 
-	dataService, err := m.ensureDataService(ctx, deployment)
+	dataService, err := m.ensureServices(ctx, podTemplate)
 	if dataService == nil || err != nil {
 		return nil, nil, err
 	}
 
+	// Syncthing can't be reached with the API key until it has been restarted with it
+	rolledOut, err := m.isAPIKeyRolledOut(ctx, podTemplate)
+	if !rolledOut || err != nil {
+		return nil, nil, err
+	}
+
+	// without an API Service, the API is reached through the mover's pod
+	if m.podLocalAPI && m.apiConfig.APIURL == "" {
+		if m.apiConfig.APIURL, err = m.getMoverPodAPIAddress(ctx); m.apiConfig.APIURL == "" || err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return dataService, secretAPIKey, nil
 }
 
 // interactWithSyncthing Updates the Syncthing instance with the required connections as defined by VolSync,
-// and sets the status of the ReplicationSource to reflect the current state of the Syncthing instance.
-// An error is returned when it is unable to do so.
-func (m *Mover) interactWithSyncthing(dataService *corev1.Service, apiSecret *corev1.Secret) error {
+// and sets the status of the ReplicationSource to reflect the current state of the Syncthing instance,
+// which is returned. An error is returned when it is unable to do so.
+func (m *Mover) interactWithSyncthing(ctx context.Context, dataService *corev1.Service,
+	apiSecret *corev1.Secret) (*api.Syncthing, error) {
 	// get the API key from the secret
 	var err error
 	if err = m.validatePeerList(); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = m.configureSyncthingAPIClient(apiSecret); err != nil {
-		return err
+		return nil, err
 	}
 
 	// fetch the latest data from Syncthing
-	syncthingState, err := m.syncthingConnection.Fetch()
+	syncthingState, err := m.syncthingConnection.Fetch(ctx)
 	if err != nil {
-		return err
+		return nil, m.reportAPIError(err, "fetch the state of Syncthing")
 	}
 
 	// configure syncthing before grabbing info & updating status
-	if err = m.ensureIsConfigured(apiSecret, syncthingState); err != nil {
-		return err
+	if err = m.ensureIsConfigured(ctx, apiSecret, syncthingState); err != nil {
+		return nil, err
+	}
+	if err = m.ensureRenderedConfig(ctx, &syncthingState.Configuration); err != nil {
+		return nil, err
 	}
 
 	// obtain the latest state
-	if syncthingState, err = m.syncthingConnection.Fetch(); err != nil {
-		return err
+	if syncthingState, err = m.syncthingConnection.Fetch(ctx); err != nil {
+		return nil, m.reportAPIError(err, "fetch the state of Syncthing")
 	}
 
+	m.fetchFolderDetails(ctx, syncthingState)
 	if err = m.ensureStatusIsUpdated(dataService, syncthingState); err != nil {
+		return nil, err
+	}
+	m.checkPeersAreReachable(ctx)
+	if err = m.ensureAddressAnnotation(ctx); err != nil {
+		return nil, err
+	}
+	if err = m.ensureStatsSnapshots(ctx); err != nil {
+		return nil, err
+	}
+	if err = m.ensureIndexSnapshots(ctx); err != nil {
+		return nil, err
+	}
+	return syncthingState, nil
+}
+
+// setOwnerUIDAnnotation Annotates the given object with the UID of the ReplicationSource, or removes
+// the annotation once it's no longer requested.
+func (m *Mover) setOwnerUIDAnnotation(obj metav1.Object) {
+	annotations := obj.GetAnnotations()
+	if !m.annotateOwnerUID {
+		if _, found := annotations[ownerUIDAnnotation]; found {
+			delete(annotations, ownerUIDAnnotation)
+			obj.SetAnnotations(annotations)
+		}
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ownerUIDAnnotation] = string(m.owner.GetUID())
+	obj.SetAnnotations(annotations)
+}
+
+// patchOwnerUIDAnnotation Keeps the owner UID annotation of an existing object in sync, patching the
+// object only when the annotation changes.
+func (m *Mover) patchOwnerUIDAnnotation(ctx context.Context, obj client.Object) error {
+	current, found := obj.GetAnnotations()[ownerUIDAnnotation]
+	if found == m.annotateOwnerUID && (!found || current == string(m.owner.GetUID())) {
+		return nil
+	}
+	original, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unable to copy %s", client.ObjectKeyFromObject(obj))
+	}
+	m.setOwnerUIDAnnotation(obj)
+	return m.client.Patch(ctx, obj, client.MergeFrom(original))
+}
+
+// ensureAddressAnnotation Keeps the address annotation on the ReplicationSource in sync with the
+// address reported in the status, removing it when the address isn't mirrored.
+func (m *Mover) ensureAddressAnnotation(ctx context.Context) error {
+	address := ""
+	if m.annotateAddress {
+		address = m.status.Address
+	}
+	current, found := m.owner.GetAnnotations()[addressAnnotation]
+	if found == (address != "") && current == address {
+		return nil
+	}
+
+	// patch a copy of the owner, so the status which hasn't been written yet isn't replaced
+	// by the one returned by the API server
+	owner, ok := m.owner.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unable to copy the owner of the mover")
+	}
+	patch := client.MergeFrom(m.owner)
+	annotations := owner.GetAnnotations()
+	if address == "" {
+		delete(annotations, addressAnnotation)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[addressAnnotation] = address
+	}
+	owner.SetAnnotations(annotations)
+	if err := m.client.Patch(ctx, owner, patch); err != nil {
+		m.logger.Error(err, "error updating the address annotation")
 		return err
 	}
+	m.owner.SetAnnotations(owner.GetAnnotations())
+	m.owner.SetResourceVersion(owner.GetResourceVersion())
 	return nil
 }
 
+// isReady Determines whether the mover has reached a steady state, where its pod is ready,
+// the given state fetched from Syncthing's API matches the spec, and all of the peers are connected.
+func (m *Mover) isReady(ctx context.Context, syncthing *api.Syncthing) (bool, error) {
+	podReady, err := m.isPodReady(ctx)
+	if !podReady || err != nil {
+		return false, err
+	}
+	configured, err := m.isConfigApplied(syncthing)
+	if !configured || err != nil {
+		return false, err
+	}
+	return allPeersConnected(m.peerList, m.status.Peers, syncthing.MyID()), nil
+}
+
+// isPodReady Returns 'true' when one of the mover's pods is ready.
+func (m *Mover) isPodReady(ctx context.Context) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := m.client.List(ctx, pods, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels(m.serviceSelector())); err != nil {
+		return false, err
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isConfigApplied Checks whether the given Syncthing configuration matches the spec, without
+// modifying it, by applying the spec to a copy and checking that nothing changed.
+func (m *Mover) isConfigApplied(syncthing *api.Syncthing) (bool, error) {
+	configCopy := &api.Syncthing{
+		Configuration: syncthing.Configuration.Copy(),
+		SystemStatus:  syncthing.SystemStatus,
+	}
+	if syncthingNeedsReconfigure(m.peerList, configCopy) {
+		return false, nil
+	}
+	if m.manageFolders && !hasManagedFolder(configCopy) {
+		return false, nil
+	}
+	if m.manageFolders && updateSyncthingFolders(m.folder, configCopy) {
+		return false, nil
+	}
+	optionsChanged, err := updateSyncthingOptions(m.options, configCopy)
+	if err != nil {
+		return false, err
+	}
+	return !optionsChanged, nil
+}
+
+// getConfigPVCName Returns the name of the PVC persisting Syncthing's config data, including its index.
+func (m *Mover) getConfigPVCName() string {
+	return resourcePrefix + m.owner.GetName() + "-config"
+}
+
 // ensureConfigPVC Ensures that there is a PVC persisting Syncthing's config data.
 func (m *Mover) ensureConfigPVC(
 	ctx context.Context,
 	dataPVC *corev1.PersistentVolumeClaim,
 ) (*corev1.PersistentVolumeClaim, error) {
+	configName := m.getConfigPVCName()
+
 	// default capacity if none was specified
 	var capacity *resource.Quantity = m.configCapacity
 	if capacity == nil {
 		cap := resource.MustParse(configCapacity)
 		capacity = &cap
 	}
+	if m.autoSizeConfig {
+		var err error
+		if capacity, err = m.getAutoSizedConfigCapacity(ctx, configName, *capacity); err != nil {
+			return nil, err
+		}
+	}
 
 	options := volsyncv1alpha1.ReplicationSourceVolumeOptions{
 		CopyMethod: volsyncv1alpha1.CopyMethodDirect,
@@ -280,9 +665,30 @@ func (m *Mover) ensureConfigPVC(
 	}
 
 	// Allocate the config volume
-	configName := resourcePrefix + m.owner.GetName() + "-config"
 	m.logger.Info("allocating config volume", "PVC", configName)
-	return configVh.EnsureNewPVC(ctx, m.logger, configName)
+	configPVC, err := configVh.EnsureNewPVC(ctx, m.logger, configName)
+	if errors.IsAlreadyExists(err) {
+		// the PVC was created by a concurrent reconcile, it can be picked up on a second try
+		return configVh.EnsureNewPVC(ctx, m.logger, configName)
+	}
+	return configPVC, err
+}
+
+// getAutoSizedConfigCapacity Returns the capacity of the config volume scaled to the number of folders
+// shared by Syncthing, without going below the capacity already requested by an existing volume.
+func (m *Mover) getAutoSizedConfigCapacity(ctx context.Context, configName string,
+	base resource.Quantity) (*resource.Quantity, error) {
+	capacity := scaleConfigCapacity(base, len(m.status.Folders))
+
+	configPVC := &corev1.PersistentVolumeClaim{}
+	err := m.client.Get(ctx, client.ObjectKey{Name: configName, Namespace: m.owner.GetNamespace()}, configPVC)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if current, ok := configPVC.Spec.Resources.Requests[corev1.ResourceStorage]; ok && current.Cmp(capacity) > 0 {
+		capacity = current
+	}
+	return &capacity, nil
 }
 
 // ensureDataPVC Ensures that the PVC holding the data meant to be synced is available.
@@ -299,6 +705,18 @@ func (m *Mover) ensureDataPVC(ctx context.Context) (*corev1.PersistentVolumeClai
 		return nil, err
 	}
 
+	switch dataPVC.Status.Phase {
+	case corev1.ClaimBound:
+	case corev1.ClaimLost:
+		return nil, fmt.Errorf("the volume bound to %s has been lost",
+			utils.KindAndName(m.client.Scheme(), dataPVC))
+	default:
+		// A PVC using a WaitForFirstConsumer StorageClass is only bound once the mover's pod is
+		// scheduled, so an unbound PVC must not hold back the creation of the workload.
+		m.logger.V(1).Info("data PVC is not bound yet, it will be bound once the mover is scheduled",
+			"PVC", client.ObjectKeyFromObject(dataPVC))
+	}
+
 	return dataPVC, nil
 }
 
@@ -324,6 +742,15 @@ func (m *Mover) ensureSecretAPIKey(ctx context.Context) (*corev1.Secret, error)
 
 	// make sure we don't need to do extra work
 	if err == nil {
+		if len(secret.Data[m.apiKeySecretKey]) == 0 {
+			m.logger.Info("API key is missing from the secret, regenerating it",
+				"secret", client.ObjectKeyFromObject(secret))
+			return m.regenerateAPIKey(ctx, secret)
+		}
+		if m.isAPIKeyRotationDue(secret) {
+			m.logger.Info("rotating the API key", "secret", client.ObjectKeyFromObject(secret))
+			return m.regenerateAPIKey(ctx, secret)
+		}
 		return secret, nil
 	} else if !errors.IsNotFound(err) {
 		return nil, err
@@ -351,11 +778,11 @@ func (m *Mover) ensureSecretAPIKey(ctx context.Context) (*corev1.Secret, error)
 	// create a new secret with the generated values
 	secret.Type = corev1.SecretTypeOpaque
 	secret.Data = map[string][]byte{
-		apiKeyDataKey:    []byte(randomAPIKey),
-		usernameDataKey:  []byte("syncthing"),
-		passwordDataKey:  []byte(randomPassword),
-		httpsCertDataKey: certPEM.Bytes(),
-		httpsKeyDataKey:  certPrivKeyPEM.Bytes(),
+		m.apiKeySecretKey: []byte(randomAPIKey),
+		usernameDataKey:   []byte("syncthing"),
+		passwordDataKey:   []byte(randomPassword),
+		httpsCertDataKey:  certPEM.Bytes(),
+		httpsKeyDataKey:   certPrivKeyPEM.Bytes(),
 	}
 
 	// ensure secret can be deleted once ReplicationSource is deleted
@@ -365,15 +792,280 @@ func (m *Mover) ensureSecretAPIKey(ctx context.Context) (*corev1.Secret, error)
 	}
 	utils.SetOwnedByVolSync(secret)
 	if err := m.client.Create(ctx, secret); err != nil {
-		return nil, err
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		// the secret was created by a concurrent reconcile, use that one instead
+		if err := m.client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+			return nil, err
+		}
+		return secret, nil
 	}
 	m.logger.Info("created secret", secret.Name, secret)
 	return secret, nil
 }
 
+// regenerateAPIKey Generates a new API key for the given secret, which exists but has lost its API key,
+// e.g. due to an external tool blanking it, or whose key is due to be rotated. Syncthing is restarted
+// to pick up the new key, as the API key hash is part of the Deployment's pod template.
+func (m *Mover) regenerateAPIKey(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+	randomAPIKey, err := GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[m.apiKeySecretKey] = []byte(randomAPIKey)
+	if m.apiKeyRotationInterval != nil {
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, apiKeyRotatedAtAnnotation,
+			m.clock.Now().UTC().Format(time.RFC3339))
+	}
+	if err := m.client.Update(ctx, secret); err != nil {
+		m.logger.Error(err, "could not update the API key", "secret", client.ObjectKeyFromObject(secret))
+		return nil, err
+	}
+	// the old key must not be used once it has been replaced
+	m.apiConfig.APIKey = ""
+	return secret, nil
+}
+
+// isAPIKeyRotationDue Determines whether the API key in the given secret is older than the
+// apiKeyRotationInterval. Keys which were never rotated are as old as their secret.
+func (m *Mover) isAPIKeyRotationDue(secret *corev1.Secret) bool {
+	if m.apiKeyRotationInterval == nil || m.apiKeyRotationInterval.Duration <= 0 {
+		return false
+	}
+	rotatedAt := secret.CreationTimestamp.Time
+	if value, ok := secret.Annotations[apiKeyRotatedAtAnnotation]; ok {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			rotatedAt = parsed
+		}
+	}
+	return !m.clock.Now().Before(rotatedAt.Add(m.apiKeyRotationInterval.Duration))
+}
+
+// isAPIKeyRolledOut Returns 'true' once none of the mover's pods are running with an API key other
+// than the one in the given pod template, so VolSync never uses a key Syncthing doesn't have.
+func (m *Mover) isAPIKeyRolledOut(ctx context.Context, podTemplate *corev1.PodTemplateSpec) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := m.client.List(ctx, pods, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels(m.serviceSelector())); err != nil {
+		return false, err
+	}
+	apiKeyHash := podTemplate.Annotations[apiKeyHashAnnotation]
+	for _, pod := range pods.Items {
+		if podHash, ok := pod.Annotations[apiKeyHashAnnotation]; ok && podHash != apiKeyHash {
+			m.logger.V(1).Info("waiting for the mover to restart with the current API key", "pod", pod.Name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ensureAPICertificate Ensures that the Secret holding the user-provided certificate for the
+// Syncthing API is usable, and loads the certificate VolSync needs to trust when connecting to the API.
+// Nothing is done when no certificate was provided.
+func (m *Mover) ensureAPICertificate(ctx context.Context) error {
+	if m.apiCertSecretName == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *m.apiCertSecretName,
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+		m.logger.Error(err, "could not get the API certificate secret", "secret", client.ObjectKeyFromObject(secret))
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if len(secret.Data[key]) == 0 {
+			return fmt.Errorf("API certificate secret %s is missing the %q key", secret.Name, key)
+		}
+	}
+
+	// prefer the CA when one is provided, so the certificate can be rotated without disruption
+	m.apiCertPEM = secret.Data[caCertDataKey]
+	if len(m.apiCertPEM) == 0 {
+		m.apiCertPEM = secret.Data[corev1.TLSCertKey]
+	}
+
+	// Syncthing only loads the certificate on startup, so the pod is rolled out again once it's rotated
+	certHash := sha256.New()
+	certHash.Write(secret.Data[corev1.TLSCertKey])
+	certHash.Write(secret.Data[corev1.TLSPrivateKeyKey])
+	m.apiCertHash = hex.EncodeToString(certHash.Sum(nil))
+	return nil
+}
+
+// ensureEncryptionPasswords Loads the passwords the folders are encrypted with for untrusted peers,
+// from the Secret named in the spec. The passwords are never logged.
+func (m *Mover) ensureEncryptionPasswords(ctx context.Context) error {
+	m.encryptionPasswords = nil
+	if m.encryptionSecretName == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *m.encryptionSecretName,
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+		m.logger.Error(err, "could not get the encryption password secret", "secret", client.ObjectKeyFromObject(secret))
+		return err
+	}
+	m.encryptionPasswords = make(map[string]string, len(secret.Data))
+	for peerID, password := range secret.Data {
+		m.encryptionPasswords[peerID] = string(password)
+	}
+	return nil
+}
+
+// ensureWorkload Ensures that the workload running the Syncthing mover exists, as selected by the spec,
+// and returns the pod template of the workload, which the Services route to. As two Syncthing instances
+// must never share the same PVCs, no pod template is returned until a workload of the other kind is gone.
+func (m *Mover) ensureWorkload(ctx context.Context, dataPVC *corev1.PersistentVolumeClaim,
+	configPVC *corev1.PersistentVolumeClaim, sa *corev1.ServiceAccount,
+	apiSecret *corev1.Secret) (*corev1.PodTemplateSpec, error) {
+	workloadMeta := metav1.ObjectMeta{
+		Name:      resourcePrefix + m.owner.GetName(),
+		Namespace: m.owner.GetNamespace(),
+	}
+
+	// the volumes can't be shared with a Job left over from older versions of the mover
+	gone, err := m.ensureLegacyJobIsGone(ctx, workloadMeta, dataPVC, configPVC)
+	if !gone || err != nil {
+		return nil, err
+	}
+
+	if m.workloadType != volsyncv1alpha1.SyncthingWorkloadStatefulSet {
+		gone, err := m.ensureWorkloadIsGone(ctx, &appsv1.StatefulSet{ObjectMeta: workloadMeta})
+		if !gone || err != nil {
+			return nil, err
+		}
+		deployment, err := m.ensureDeployment(ctx, dataPVC, configPVC, sa, apiSecret)
+		if deployment == nil || err != nil {
+			return nil, err
+		}
+		return &deployment.Spec.Template, nil
+	}
+
+	gone, err = m.ensureWorkloadIsGone(ctx, &appsv1.Deployment{ObjectMeta: workloadMeta})
+	if !gone || err != nil {
+		return nil, err
+	}
+	statefulSet, err := m.ensureStatefulSet(ctx, dataPVC, configPVC, sa, apiSecret)
+	if statefulSet == nil || err != nil {
+		return nil, err
+	}
+	if _, err := m.ensurePeerService(ctx, &statefulSet.Spec.Template); err != nil {
+		return nil, err
+	}
+	return &statefulSet.Spec.Template, nil
+}
+
+// ensureLegacyJobIsGone Deletes the Job that ran the mover before it moved to a long-running
+// workload, and returns 'true' once it no longer holds the data or config volumes.
+// Jobs which aren't owned by this mover or don't mount its volumes are left alone.
+func (m *Mover) ensureLegacyJobIsGone(ctx context.Context, jobMeta metav1.ObjectMeta,
+	dataPVC *corev1.PersistentVolumeClaim, configPVC *corev1.PersistentVolumeClaim) (bool, error) {
+	job := &batchv1.Job{ObjectMeta: jobMeta}
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if !metav1.IsControlledBy(job, m.owner) || !podUsesClaims(&job.Spec.Template.Spec, dataPVC, configPVC) {
+		return true, nil
+	}
+	// foreground deletion keeps the Job around until its pods, and their volumes, are gone
+	return m.ensureWorkloadIsGone(ctx, job)
+}
+
+// podUsesClaims Returns 'true' if the pod mounts any of the given PVCs.
+func podUsesClaims(podSpec *corev1.PodSpec, pvcs ...*corev1.PersistentVolumeClaim) bool {
+	for _, volume := range podSpec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		for _, pvc := range pvcs {
+			if pvc != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ensureWorkloadIsGone Deletes the given workload along with its pods, and returns 'true'
+// once it no longer exists.
+func (m *Mover) ensureWorkloadIsGone(ctx context.Context, workload client.Object) (bool, error) {
+	logger := m.logger.WithValues("workload", client.ObjectKeyFromObject(workload))
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(workload), workload); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if workload.GetDeletionTimestamp().IsZero() {
+		logger.Info("deleting the workload previously used by the mover")
+		err := m.client.Delete(ctx, workload, client.PropagationPolicy(metav1.DeletePropagationForeground))
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "unable to delete the workload")
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// ensureHostNode Looks up the node the mover's pod has been scheduled to, so that it can be
+// kept there and its address advertised. The node is left unset until the pod is scheduled.
+// Pods being terminated are skipped, as their node may not be the one the mover is moving to.
+func (m *Mover) ensureHostNode(ctx context.Context) error {
+	pods := &corev1.PodList{}
+	if err := m.client.List(ctx, pods, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels(m.serviceSelector())); err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.Spec.NodeName == "" {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := m.client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+			return err
+		}
+		m.hostNode = node
+		return nil
+	}
+	return nil
+}
+
+// getAffinity Determines the affinity of the mover's pod from the users of the data PVC.
+// When the data port is bound on the host, the pod is also kept on the node it's running on.
+func (m *Mover) getAffinity(ctx context.Context, logger logr.Logger,
+	dataPVC *corev1.PersistentVolumeClaim) (*utils.AffinityInfo, error) {
+	affinity, err := utils.AffinityFromVolume(ctx, m.client, logger, dataPVC)
+	if err != nil {
+		logger.Error(err, "unable to determine proper affinity", "PVC", client.ObjectKeyFromObject(dataPVC))
+		return nil, err
+	}
+	if m.useHostPort && m.hostNode != nil && len(affinity.NodeSelector) == 0 {
+		affinity.NodeSelector = map[string]string{
+			corev1.LabelHostname: m.hostNode.Labels[corev1.LabelHostname],
+		}
+	}
+	return affinity, nil
+}
+
 // ensureDeployment Will ensure that a Deployment for the Syncthing mover exists, or it will be created.
-//
-//nolint:funlen
 func (m *Mover) ensureDeployment(ctx context.Context, dataPVC *corev1.PersistentVolumeClaim,
 	configPVC *corev1.PersistentVolumeClaim, sa *corev1.ServiceAccount,
 	apiSecret *corev1.Secret) (*appsv1.Deployment, error) {
@@ -393,19 +1085,19 @@ func (m *Mover) ensureDeployment(ctx context.Context, dataPVC *corev1.Persistent
 	}
 	logger := m.logger.WithValues("deployment", client.ObjectKeyFromObject(deployment))
 
-	affinity, err := utils.AffinityFromVolume(ctx, m.client, logger, dataPVC)
+	affinity, err := m.getAffinity(ctx, logger, dataPVC)
 	if err != nil {
-		logger.Error(err, "unable to determine proper affinity", "PVC", client.ObjectKeyFromObject(dataPVC))
 		return nil, err
 	}
 
 	// we declare the deployment object in this block line-by-line
-	_, err = ctrlutil.CreateOrUpdate(ctx, m.client, deployment, func() error {
+	_, err = m.createOrUpdate(ctx, deployment, func() error {
 		if err := ctrl.SetControllerReference(m.owner, deployment, m.client.Scheme()); err != nil {
 			logger.Error(err, utils.ErrUnableToSetControllerRef)
 			return err
 		}
 		utils.SetOwnedByVolSync(deployment)
+		m.setOwnerUIDAnnotation(deployment)
 
 		deployment.Spec.Replicas = &numReplicas
 		deployment.Spec.Selector = &metav1.LabelSelector{
@@ -416,141 +1108,408 @@ func (m *Mover) ensureDeployment(ctx context.Context, dataPVC *corev1.Persistent
 			Type: appsv1.RecreateDeploymentStrategyType,
 		}
 
-		deployment.Spec.Template = corev1.PodTemplateSpec{}
-		utils.SetOwnedByVolSync(&deployment.Spec.Template)
-		deployment.Spec.Template.ObjectMeta.Name = deployment.Name
-		utils.AddAllLabels(&deployment.Spec.Template, m.serviceSelector())
+		m.setPodTemplate(&deployment.Spec.Template, deployment.Name, affinity, dataPVC, configPVC, sa, apiSecret)
+		return nil
+	})
 
-		podSpec := &deployment.Spec.Template.Spec
+	// error from createOrUpdate against a deployment indicates an issue
+	if err != nil {
+		m.logger.Error(err, "unable to create deployment")
+		return nil, err
+	}
 
-		podSpec.NodeSelector = affinity.NodeSelector
-		podSpec.Tolerations = affinity.Tolerations
+	return deployment, nil
+}
 
-		podSpec.ServiceAccountName = sa.Name
-		podSpec.RestartPolicy = corev1.RestartPolicyAlways
-		podSpec.TerminationGracePeriodSeconds = pointer.Int64(10)
+// ensureStatefulSet Will ensure that a single-replica StatefulSet for the Syncthing mover exists,
+// or it will be created. Its pod is given a stable DNS name by the peer Service.
+func (m *Mover) ensureStatefulSet(ctx context.Context, dataPVC *corev1.PersistentVolumeClaim,
+	configPVC *corev1.PersistentVolumeClaim, sa *corev1.ServiceAccount,
+	apiSecret *corev1.Secret) (*appsv1.StatefulSet, error) {
+	var numReplicas int32 = 1
 
-		envVars := []corev1.EnvVar{
-			{Name: configDirEnv, Value: configDirMountPath},
-			{Name: dataDirEnv, Value: dataDirMountPath},
-			// tell the mover image where to find the HTTPS certs
-			{Name: certDirEnv, Value: certDirMountPath},
-			{
-				Name: apiKeyEnv,
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: apiSecret.Name,
-						},
-						Key: apiKeyDataKey,
-					},
-				},
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourcePrefix + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+			Labels: map[string]string{
+				"app": m.owner.GetName(),
 			},
-		}
+		},
+	}
+	logger := m.logger.WithValues("statefulset", client.ObjectKeyFromObject(statefulSet))
 
-		// Cluster-wide proxy settings
-		envVars = utils.AppendEnvVarsForClusterWideProxy(envVars)
+	affinity, err := m.getAffinity(ctx, logger, dataPVC)
+	if err != nil {
+		return nil, err
+	}
 
-		podSpec.Containers = []corev1.Container{
-			{
-				Name:    "syncthing",
-				Image:   m.containerImage,
-				Command: []string{"/mover-syncthing/entry.sh"},
-				Args:    []string{"run"},
-				Env:     envVars,
-				Ports: []corev1.ContainerPort{
-					{Name: apiPortName, ContainerPort: apiPort},
-					{Name: dataPortName, ContainerPort: dataPort},
-				},
-				VolumeMounts: []corev1.VolumeMount{
-					{Name: configVolumeName, MountPath: configDirMountPath},
-					{Name: dataVolumeName, MountPath: dataDirMountPath},
-					{Name: certVolumeName, MountPath: certDirMountPath},
-				},
-				Resources: corev1.ResourceRequirements{
-					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
-				},
-				SecurityContext: &corev1.SecurityContext{
-					AllowPrivilegeEscalation: pointer.Bool(false),
-					Capabilities: &corev1.Capabilities{
-						Drop: []corev1.Capability{"ALL"},
-					},
-					Privileged:             pointer.Bool(false),
-					ReadOnlyRootFilesystem: pointer.Bool(true),
-				},
-			},
+	_, err = m.createOrUpdate(ctx, statefulSet, func() error {
+		if err := ctrl.SetControllerReference(m.owner, statefulSet, m.client.Scheme()); err != nil {
+			logger.Error(err, utils.ErrUnableToSetControllerRef)
+			return err
+		}
+		utils.SetOwnedByVolSync(statefulSet)
+		m.setOwnerUIDAnnotation(statefulSet)
+
+		// the selector & service name are immutable, so they're only set on creation
+		if statefulSet.CreationTimestamp.IsZero() {
+			statefulSet.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: m.serviceSelector(),
+			}
+			statefulSet.Spec.ServiceName = m.getPeerServiceName()
 		}
+		statefulSet.Spec.Replicas = &numReplicas
 
-		// security context
-		podSpec.SecurityContext = m.moverSecurityContext
+		m.setPodTemplate(&statefulSet.Spec.Template, statefulSet.Name, affinity, dataPVC, configPVC, sa, apiSecret)
+		return nil
+	})
+	if err != nil {
+		m.logger.Error(err, "unable to create statefulset")
+		return nil, err
+	}
+
+	return statefulSet, nil
+}
 
-		// configure volumes
-		podSpec.Volumes = []corev1.Volume{
+// ensurePeerService Ensures that the headless Service giving the StatefulSet's pod a stable DNS name exists.
+func (m *Mover) ensurePeerService(ctx context.Context, podTemplate *corev1.PodTemplateSpec) (*corev1.Service, error) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.getPeerServiceName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("service", client.ObjectKeyFromObject(service))
+
+	_, err := m.createOrUpdate(ctx, service, func() error {
+		if err := ctrl.SetControllerReference(m.owner, service, m.client.Scheme()); err != nil {
+			logger.Error(err, utils.ErrUnableToSetControllerRef)
+			return err
+		}
+		utils.SetOwnedByVolSync(service)
+		m.setOwnerUIDAnnotation(service)
+
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+		service.Spec.Selector = podTemplate.Labels
+		service.Spec.Ports = []corev1.ServicePort{
 			{
-				Name: configVolumeName,
-				VolumeSource: corev1.VolumeSource{
-					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: configPVC.Name,
+				Port:       dataPort,
+				TargetPort: intstr.FromInt(dataPort),
+				Protocol:   "TCP",
+				Name:       dataPortName,
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// getHealthCheckPath Returns the path of the API endpoint reporting whether Syncthing is healthy.
+func (m *Mover) getHealthCheckPath() string {
+	if m.healthCheckPath != nil {
+		return *m.healthCheckPath
+	}
+	return defaultHealthCheckPath
+}
+
+// setPodTemplate Sets the given pod template to run Syncthing with the provided volumes & secrets,
+// for use by the workload managing the mover.
+//
+//nolint:funlen
+func (m *Mover) setPodTemplate(template *corev1.PodTemplateSpec, name string, affinity *utils.AffinityInfo,
+	dataPVC *corev1.PersistentVolumeClaim, configPVC *corev1.PersistentVolumeClaim,
+	sa *corev1.ServiceAccount, apiSecret *corev1.Secret) {
+	*template = corev1.PodTemplateSpec{}
+	utils.SetOwnedByVolSync(template)
+	template.ObjectMeta.Name = name
+	utils.AddAllLabels(template, m.serviceSelector())
+	// Syncthing only reads the API key on startup, so roll out a new pod whenever it changes
+	apiKeyHash := sha256.Sum256(apiSecret.Data[m.apiKeySecretKey])
+	template.Annotations = map[string]string{
+		apiKeyHashAnnotation: hex.EncodeToString(apiKeyHash[:]),
+	}
+	if m.apiCertHash != "" {
+		template.Annotations[apiCertHashAnnotation] = m.apiCertHash
+	}
+
+	podSpec := &template.Spec
+
+	podSpec.NodeSelector = affinity.NodeSelector
+	podSpec.Tolerations = affinity.Tolerations
+
+	podSpec.ServiceAccountName = sa.Name
+	podSpec.RestartPolicy = corev1.RestartPolicyAlways
+	podSpec.TerminationGracePeriodSeconds = pointer.Int64(10)
+	if m.schedulerName != nil {
+		podSpec.SchedulerName = *m.schedulerName
+	}
+	// the Services' env vars are only injected when asked for, as they may collide with Syncthing's
+	podSpec.EnableServiceLinks = pointer.Bool(m.enableServiceLinks != nil && *m.enableServiceLinks)
+
+	envVars := []corev1.EnvVar{
+		{Name: configDirEnv, Value: configDirMountPath},
+		{Name: dataDirEnv, Value: dataDirMountPath},
+		// tell the mover image where to find the HTTPS certs
+		{Name: certDirEnv, Value: certDirMountPath},
+		{
+			Name: apiKeyEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: apiSecret.Name,
 					},
+					Key: m.apiKeySecretKey,
 				},
 			},
-			{
-				Name: dataVolumeName,
-				VolumeSource: corev1.VolumeSource{
-					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: dataPVC.Name,
+		},
+	}
+
+	// Cluster-wide proxy settings
+	envVars = utils.AppendEnvVarsForClusterWideProxy(envVars)
+
+	podSpec.Containers = []corev1.Container{
+		{
+			Name:            "syncthing",
+			Image:           m.containerImage,
+			ImagePullPolicy: m.getImagePullPolicy(),
+			Command:         []string{"/mover-syncthing/entry.sh"},
+			Args:            []string{"run"},
+			Env:             envVars,
+			Ports: []corev1.ContainerPort{
+				{Name: apiPortName, ContainerPort: apiPort},
+				{Name: dataPortName, ContainerPort: dataPort, HostPort: m.getHostPort()},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: m.configVolumeName, MountPath: configDirMountPath},
+				{Name: m.dataVolumeName, MountPath: dataDirMountPath},
+				{Name: certVolumeName, MountPath: certDirMountPath},
+			},
+			// the pod is only ready once Syncthing serves its API
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path:   m.getHealthCheckPath(),
+						Port:   intstr.FromString(apiPortName),
+						Scheme: corev1.URISchemeHTTPS,
 					},
 				},
+				PeriodSeconds: 5,
 			},
-			// load the HTTPS certs as a volume
-			{
-				Name: certVolumeName,
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName:  apiSecret.Name,
-						DefaultMode: pointer.Int32(0600),
-						Items: []corev1.KeyToPath{
-							{Key: httpsKeyDataKey, Path: httpsKeyPath},
-							{Key: httpsCertDataKey, Path: httpsCertPath},
-						},
-					},
+			Resources:                m.getMoverResources(),
+			TerminationMessagePolicy: m.terminationMessagePolicy,
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				Capabilities: &corev1.Capabilities{
+					Drop: []corev1.Capability{"ALL"},
+				},
+				Privileged:             pointer.Bool(false),
+				ReadOnlyRootFilesystem: pointer.Bool(true),
+				// required by the restricted Pod Security Standard
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
 				},
 			},
-		}
+		},
+	}
 
-		if m.privileged {
-			podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
-				Name:  "PRIVILEGED_MOVER",
-				Value: "1",
-			})
-			podSpec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{
-				"DAC_OVERRIDE", // Read/write all files
-				"CHOWN",        // chown files
-				"FOWNER",       // Set permission bits & times
-			}
-			podSpec.Containers[0].SecurityContext.RunAsUser = pointer.Int64(0)
-			podSpec.Containers[0].SecurityContext.RunAsNonRoot = pointer.Bool(false)
-		} else {
+	// hold off on marking the container as started until Syncthing is healthy
+	if m.startupHealthTimeout != nil {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  healthTimeoutEnv,
+			Value: strconv.Itoa(int(*m.startupHealthTimeout)),
+		})
+		// the image checks the standard endpoint unless told otherwise
+		if m.healthCheckPath != nil {
 			podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
-				Name:  "PRIVILEGED_MOVER",
-				Value: "0",
+				Name:  healthPathEnv,
+				Value: *m.healthCheckPath,
 			})
 		}
+		podSpec.Containers[0].Lifecycle = &corev1.Lifecycle{
+			PostStart: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"/mover-syncthing/entry.sh", "wait-healthy"},
+				},
+			},
+		}
+	}
 
-		return nil
-	})
+	m.setDebugOptions(&podSpec.Containers[0])
+
+	// security context
+	// runAsNonRoot, runAsUser and fsGroup are left to the spec: the image runs as root unless a user is set,
+	// and on OpenShift these are assigned from the namespace's range, which fixed defaults would conflict with
+	podSpec.SecurityContext = m.moverSecurityContext
+	if len(m.sysctls) > 0 {
+		// copied so the sysctls aren't added to the spec's security context
+		podSpec.SecurityContext = m.moverSecurityContext.DeepCopy()
+		if podSpec.SecurityContext == nil {
+			podSpec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		podSpec.SecurityContext.Sysctls = append(podSpec.SecurityContext.Sysctls, m.sysctls...)
+	}
 
-	// error from createOrUpdate against a deployment indicates an issue
-	if err != nil {
-		m.logger.Error(err, "unable to create deployment")
+	// load the HTTPS certs as a volume, serving the user-provided certificate instead of the generated one
+	certSecretSource := &corev1.SecretVolumeSource{
+		SecretName:  apiSecret.Name,
+		DefaultMode: pointer.Int32(0600),
+		Items: []corev1.KeyToPath{
+			{Key: httpsKeyDataKey, Path: httpsKeyPath},
+			{Key: httpsCertDataKey, Path: httpsCertPath},
+		},
+	}
+	if m.apiCertSecretName != nil {
+		certSecretSource.SecretName = *m.apiCertSecretName
+		certSecretSource.Items = []corev1.KeyToPath{
+			{Key: corev1.TLSPrivateKeyKey, Path: httpsKeyPath},
+			{Key: corev1.TLSCertKey, Path: httpsCertPath},
+		}
+	}
+
+	// configure volumes
+	podSpec.Volumes = []corev1.Volume{
+		{
+			Name: m.configVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: configPVC.Name,
+				},
+			},
+		},
+		{
+			Name: m.dataVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: dataPVC.Name,
+				},
+			},
+		},
+		{
+			Name:         certVolumeName,
+			VolumeSource: corev1.VolumeSource{Secret: certSecretSource},
+		},
+	}
+
+	if m.privileged {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "PRIVILEGED_MOVER",
+			Value: "1",
+		})
+		podSpec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{
+			"DAC_OVERRIDE", // Read/write all files
+			"CHOWN",        // chown files
+			"FOWNER",       // Set permission bits & times
+		}
+		podSpec.Containers[0].SecurityContext.RunAsUser = pointer.Int64(0)
+		podSpec.Containers[0].SecurityContext.RunAsNonRoot = pointer.Bool(false)
+	} else {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "PRIVILEGED_MOVER",
+			Value: "0",
+		})
+	}
+}
+
+// ensureServices Ensures that the Services exposing the Syncthing API & data ports exist, along with
+// the NetworkPolicy restricting access to the data port, and returns the data Service.
+func (m *Mover) ensureServices(ctx context.Context, podTemplate *corev1.PodTemplateSpec) (*corev1.Service, error) {
+	if m.podLocalAPI {
+		if err := m.ensureAPIServiceIsGone(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		APIService, err := m.ensureAPIService(ctx, podTemplate)
+		if APIService == nil || err != nil {
+			return nil, err
+		}
+	}
+
+	dataService, err := m.ensureDataService(ctx, podTemplate)
+	if dataService == nil || err != nil {
 		return nil, err
 	}
 
-	return deployment, nil
+	if err = m.ensureNetworkPolicy(ctx, podTemplate); err != nil {
+		return nil, err
+	}
+	return dataService, nil
+}
+
+// ensureNetworkPolicy Ensures that a NetworkPolicy only admits connections to the data port from the
+// allowed sources, while leaving the API port reachable by VolSync. When the API is local to the mover's
+// pod, only VolSync's own namespace may reach it. The NetworkPolicy is removed when there's nothing to
+// restrict.
+func (m *Mover) ensureNetworkPolicy(ctx context.Context, podTemplate *corev1.PodTemplateSpec) error {
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourcePrefix + m.owner.GetName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("networkPolicy", client.ObjectKeyFromObject(networkPolicy))
+
+	if len(m.allowedDataSources) == 0 && !m.podLocalAPI {
+		err := m.client.Get(ctx, client.ObjectKeyFromObject(networkPolicy), networkPolicy)
+		if errors.IsNotFound(err) || (err == nil && !metav1.IsControlledBy(networkPolicy, m.owner)) {
+			return nil
+		}
+		if err == nil {
+			err = m.client.Delete(ctx, networkPolicy)
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	// VolSync configures Syncthing through the API from the controller's namespace
+	var apiSources []networkingv1.NetworkPolicyPeer
+	if m.podLocalAPI {
+		if m.controllerNamespace == "" {
+			err := fmt.Errorf("the namespace VolSync runs in is unknown, set --%s", syncthingControllerNSFlag)
+			logger.Error(err, "unable to restrict the API to VolSync while it's local to the mover's pod")
+			return err
+		}
+		apiSources = []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{corev1.LabelMetadataName: m.controllerNamespace},
+			},
+		}}
+	}
+
+	_, err := m.createOrUpdate(ctx, networkPolicy, func() error {
+		if err := ctrl.SetControllerReference(m.owner, networkPolicy, m.client.Scheme()); err != nil {
+			logger.Error(err, utils.ErrUnableToSetControllerRef)
+			return err
+		}
+		utils.SetOwnedByVolSync(networkPolicy)
+		m.setOwnerUIDAnnotation(networkPolicy)
+
+		tcp := corev1.ProtocolTCP
+		dataTargetPort := intstr.FromString(dataPortName)
+		apiTargetPort := intstr.FromString(apiPortName)
+		networkPolicy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podTemplate.Labels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &dataTargetPort}},
+					From:  m.allowedDataSources,
+				},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &apiTargetPort}},
+					From:  apiSources,
+				},
+			},
+		}
+		return nil
+	})
+	return err
 }
 
 // ensureAPIService Ensures that a service exposing the Syncthing API is present, else it will be created.
-func (m *Mover) ensureAPIService(ctx context.Context, deployment *appsv1.Deployment) (*corev1.Service, error) {
+func (m *Mover) ensureAPIService(ctx context.Context, podTemplate *corev1.PodTemplateSpec) (*corev1.Service, error) {
 	// setup vars
 	targetPort := "api"
 	serviceName := m.getAPIServiceName()
@@ -562,15 +1521,16 @@ func (m *Mover) ensureAPIService(ctx context.Context, deployment *appsv1.Deploym
 	}
 	logger := m.logger.WithValues("service", client.ObjectKeyFromObject(service))
 
-	_, err := ctrlutil.CreateOrUpdate(ctx, m.client, service, func() error {
+	_, err := m.createOrUpdate(ctx, service, func() error {
 		if err := ctrl.SetControllerReference(m.owner, service, m.client.Scheme()); err != nil {
 			logger.Error(err, utils.ErrUnableToSetControllerRef)
 			return err
 		}
 		utils.SetOwnedByVolSync(service)
+		m.setOwnerUIDAnnotation(service)
 
-		// service should route to the deployment's pods
-		service.Spec.Selector = deployment.Spec.Template.Labels
+		// service should route to the mover's pods
+		service.Spec.Selector = podTemplate.Labels
 		service.Spec.Ports = []corev1.ServicePort{
 			{
 				Port:       apiPort,
@@ -589,10 +1549,48 @@ func (m *Mover) ensureAPIService(ctx context.Context, deployment *appsv1.Deploym
 	return service, nil
 }
 
+// ensureAPIServiceIsGone Removes the Service exposing the Syncthing API, when the API is kept local to
+// the mover's pod.
+func (m *Mover) ensureAPIServiceIsGone(ctx context.Context) error {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.getAPIServiceName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	err := m.client.Get(ctx, client.ObjectKeyFromObject(service), service)
+	if errors.IsNotFound(err) || (err == nil && !metav1.IsControlledBy(service, m.owner)) {
+		return nil
+	}
+	if err == nil {
+		m.logger.Info("removing the API service, as the API is kept local to the mover's pod",
+			"service", client.ObjectKeyFromObject(service))
+		err = m.client.Delete(ctx, service)
+	}
+	return client.IgnoreNotFound(err)
+}
+
+// getMoverPodAPIAddress Returns the address of the Syncthing API on the IP of the mover's pod,
+// or an empty string while no running pod has been assigned an IP.
+func (m *Mover) getMoverPodAPIAddress(ctx context.Context) (string, error) {
+	pods := &corev1.PodList{}
+	if err := m.client.List(ctx, pods, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels(m.serviceSelector())); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			return "https://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(apiPort)), nil
+		}
+	}
+	m.logger.V(1).Info("waiting for the mover's pod to be assigned an IP to reach the API through")
+	return "", nil
+}
+
 // ensureDataService Ensures that a service exposing the Syncthing data is present, else it will be created.
 // This service allows Syncthing to share data with the rest of the world.
-func (m *Mover) ensureDataService(ctx context.Context, deployment *appsv1.Deployment) (*corev1.Service, error) {
-	serviceName := resourcePrefix + m.owner.GetName() + "-data"
+func (m *Mover) ensureDataService(ctx context.Context, podTemplate *corev1.PodTemplateSpec) (*corev1.Service, error) {
+	serviceName := m.getDataServiceName()
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
@@ -601,15 +1599,16 @@ func (m *Mover) ensureDataService(ctx context.Context, deployment *appsv1.Deploy
 	}
 
 	logger := m.logger.WithValues("service", client.ObjectKeyFromObject(service))
-	_, err := ctrl.CreateOrUpdate(ctx, m.client, service, func() error {
+	op, err := m.createOrUpdate(ctx, service, func() error {
 		if err := ctrl.SetControllerReference(m.owner, service, m.client.Scheme()); err != nil {
 			logger.Error(err, utils.ErrUnableToSetControllerRef)
 			return err
 		}
 		utils.SetOwnedByVolSync(service)
+		m.setOwnerUIDAnnotation(service)
 
 		service.Spec.Type = m.serviceType
-		service.Spec.Selector = deployment.Spec.Template.Labels
+		service.Spec.Selector = podTemplate.Labels
 		service.Spec.Ports = []corev1.ServicePort{
 			{
 				Port:       dataPort,
@@ -620,19 +1619,63 @@ func (m *Mover) ensureDataService(ctx context.Context, deployment *appsv1.Deploy
 				Name:     dataPortName,
 			},
 		}
+		// the API is only exposed alongside the data port when explicitly requested
+		if m.exposeAPI {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+				Port:       apiPort,
+				TargetPort: intstr.FromString(apiPortName),
+				Protocol:   "TCP",
+				Name:       apiPortName,
+			})
+		}
+		// pin the node ports if requested, otherwise the cluster allocates them
+		if m.serviceType == corev1.ServiceTypeNodePort {
+			if m.dataNodePort != nil {
+				service.Spec.Ports[0].NodePort = *m.dataNodePort
+			}
+			if m.exposeAPI && m.apiNodePort != nil {
+				service.Spec.Ports[1].NodePort = *m.apiNodePort
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	if m.exposeAPI && op != ctrlutil.OperationResultNone {
+		m.eventRecorder.Eventf(m.owner, service, corev1.EventTypeWarning,
+			volsyncv1alpha1.EvRSvcAPIExposed, volsyncv1alpha1.EvANone,
+			"the Syncthing API is exposed through %s; make sure it is not reachable by untrusted clients",
+			utils.KindAndName(m.client.Scheme(), service))
+	}
 	return service, nil
 }
 
+// createOrUpdate Wraps ctrlutil.CreateOrUpdate, retrying once if the object was created by a
+// concurrent reconcile in between the Get and the Create.
+func (m *Mover) createOrUpdate(ctx context.Context, obj client.Object,
+	f ctrlutil.MutateFn) (ctrlutil.OperationResult, error) {
+	op, err := ctrlutil.CreateOrUpdate(ctx, m.client, obj, f)
+	if errors.IsAlreadyExists(err) {
+		m.logger.V(1).Info("object was created concurrently, retrying", "object", client.ObjectKeyFromObject(obj))
+		return ctrlutil.CreateOrUpdate(ctx, m.client, obj, f)
+	}
+	return op, err
+}
+
 // GetDataServiceAddress Will return a string representing the address of the data service, prefixed with TCP.
 func (m *Mover) GetDataServiceAddress(service *corev1.Service) (string, error) {
 	// format the address based on the type of service we're using
-	// supported service types: ClusterIP, LoadBalancer
-	address := utils.GetServiceAddress(service)
+	// supported service types: ClusterIP, NodePort, LoadBalancer
+	var address string
+	switch service.Spec.Type {
+	case corev1.ServiceTypeNodePort:
+		return m.getNodePortAddress(service)
+	case corev1.ServiceTypeLoadBalancer:
+		address = getLoadBalancerAddress(service)
+	default:
+		address = utils.GetServiceAddress(service)
+	}
 	if address == "" {
 		return "", fmt.Errorf("could not get an address for the service")
 	}
@@ -640,16 +1683,192 @@ func (m *Mover) GetDataServiceAddress(service *corev1.Service) (string, error) {
 	return address, nil
 }
 
+// setDebugOptions Applies the debug options from the spec to the Syncthing container, if any.
+func (m *Mover) setDebugOptions(container *corev1.Container) {
+	if m.debug == nil {
+		return
+	}
+	container.Stdin = m.debug.TTY
+	container.TTY = m.debug.TTY
+	if m.debug.Sleep {
+		// keep the container running without Syncthing, so it can be inspected
+		container.Command = []string{"sleep", "infinity"}
+		container.Args = nil
+		// Syncthing never becomes healthy, so neither the container nor the pod waits for it
+		container.Lifecycle = nil
+		container.ReadinessProbe = nil
+	}
+}
+
+// getSynchronizeTimeout Returns the time a synchronization pass may take before it is aborted.
+func (m *Mover) getSynchronizeTimeout() time.Duration {
+	if m.synchronizeTimeout != nil {
+		return m.synchronizeTimeout.Duration
+	}
+	return defaultSynchronizeTimeout
+}
+
+// getSyncInterval Returns how long VolSync waits between synchronization passes.
+func (m *Mover) getSyncInterval() time.Duration {
+	if m.syncInterval != nil {
+		return m.syncInterval.Duration
+	}
+	return synchronizeInterval
+}
+
+// getStatsSnapshotInterval Returns how often a snapshot of the statistics is taken.
+func (m *Mover) getStatsSnapshotInterval() time.Duration {
+	if m.statsSnapshots != nil && m.statsSnapshots.Interval != nil {
+		return m.statsSnapshots.Interval.Duration
+	}
+	return defaultStatsSnapshotInterval
+}
+
+// getStatsSnapshotRetain Returns the number of stats snapshots which are retained.
+func (m *Mover) getStatsSnapshotRetain() int {
+	if m.statsSnapshots != nil && m.statsSnapshots.Retain != nil {
+		return int(*m.statsSnapshots.Retain)
+	}
+	return defaultStatsSnapshotRetain
+}
+
+// getIndexSnapshotInterval Returns how often a snapshot of the index is taken.
+func (m *Mover) getIndexSnapshotInterval() time.Duration {
+	if m.indexSnapshots != nil && m.indexSnapshots.Interval != nil {
+		return m.indexSnapshots.Interval.Duration
+	}
+	return defaultIndexSnapshotInterval
+}
+
+// getIndexSnapshotRetain Returns the number of index snapshots which are retained.
+func (m *Mover) getIndexSnapshotRetain() int {
+	if m.indexSnapshots != nil && m.indexSnapshots.Retain != nil {
+		return int(*m.indexSnapshots.Retain)
+	}
+	return defaultIndexSnapshotRetain
+}
+
+// getMoverResources Returns the compute resources of the Syncthing container from the spec,
+// or the default memory limit when none are specified.
+func (m *Mover) getMoverResources() corev1.ResourceRequirements {
+	if m.moverResources != nil {
+		return *m.moverResources.DeepCopy()
+	}
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+}
+
+// getImagePullPolicy Returns the pull policy from the spec, or the one suited to the container image.
+func (m *Mover) getImagePullPolicy() corev1.PullPolicy {
+	if m.imagePullPolicy != nil {
+		return *m.imagePullPolicy
+	}
+	return imagePullPolicyFor(m.containerImage)
+}
+
+// getHostPort Returns the port the data port is bound to on the host, or 0 if it isn't.
+func (m *Mover) getHostPort() int32 {
+	if m.useHostPort {
+		return dataPort
+	}
+	return 0
+}
+
+// getHostNodeAddress Returns the address of the node the mover is running on, with the given port.
+func (m *Mover) getHostNodeAddress(port int) (string, error) {
+	if m.hostNode == nil {
+		return "", fmt.Errorf("the mover has not been scheduled to a node yet")
+	}
+	address := getNodeAddress(m.hostNode)
+	if address == "" {
+		return "", fmt.Errorf("could not get an address for node %s", m.hostNode.Name)
+	}
+	return asTCPAddress(address + ":" + strconv.Itoa(port)), nil
+}
+
+// getLoadBalancerAddress Returns the address assigned to a LoadBalancer service, preferring the IP of
+// its first ingress over the hostname that some load balancers (e.g. AWS ELB) expose instead.
+func getLoadBalancerAddress(service *corev1.Service) string {
+	if len(service.Status.LoadBalancer.Ingress) == 0 {
+		return ""
+	}
+	ingress := service.Status.LoadBalancer.Ingress[0]
+	if ingress.IP != "" {
+		return ingress.IP
+	}
+	return ingress.Hostname
+}
+
+// getNodePortAddress Returns the address of the data port of a NodePort service, reached through
+// the node the mover is running on.
+func (m *Mover) getNodePortAddress(service *corev1.Service) (string, error) {
+	for _, port := range service.Spec.Ports {
+		if port.Name == dataPortName && port.NodePort != 0 {
+			return m.getHostNodeAddress(int(port.NodePort))
+		}
+	}
+	return "", fmt.Errorf("no node port has been assigned to the data port yet")
+}
+
+// getAdvertisedAddress Returns the address that peers should use to connect to this Syncthing instance.
+// An address provided in the spec takes precedence over the one derived from the data service.
+func (m *Mover) getAdvertisedAddress(dataSVC *corev1.Service) (string, error) {
+	if m.advertisedAddress != nil {
+		if err := validateSyncthingAddress(*m.advertisedAddress); err != nil {
+			return "", err
+		}
+		return *m.advertisedAddress, nil
+	}
+	if m.useHostPort {
+		return m.getHostNodeAddress(dataPort)
+	}
+	if m.workloadType == volsyncv1alpha1.SyncthingWorkloadStatefulSet {
+		return asTCPAddress(m.getPeerPodDNS() + ":" + strconv.Itoa(dataPort)), nil
+	}
+	return m.GetDataServiceAddress(dataSVC)
+}
+
 // Cleanup will remove any resources that were created by the mover.
-// This is currently a no-op since Syncthing is always-on.
+// The workload running Syncthing and the objects derived from it are removed, while the PVCs and the
+// API key secret are kept, so that Syncthing resumes with the same identity and index once recreated.
+// The stats and index snapshots are kept as well, as they hold history which can't be recreated.
 func (m *Mover) Cleanup(ctx context.Context) (mover.Result, error) {
-	err := utils.CleanupObjects(ctx, m.client, m.logger, m.owner, []client.Object{})
-	if err != nil {
-		return mover.InProgress(), err
+	for _, obj := range m.getCleanupObjects() {
+		logger := m.logger.WithValues("object", client.ObjectKeyFromObject(obj))
+		err := m.client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if errors.IsNotFound(err) || (err == nil && !metav1.IsControlledBy(obj, m.owner)) {
+			continue
+		}
+		if err == nil {
+			logger.V(1).Info("deleting the mover's object")
+			err = m.client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		}
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "unable to delete the mover's object")
+			return mover.InProgress(), err
+		}
 	}
 	return mover.Complete(), nil
 }
 
+// getCleanupObjects Returns the objects removed by Cleanup: either kind of workload, the Services,
+// the NetworkPolicy and the ConfigMap holding the rendered config, which are recreated along with the mover.
+func (m *Mover) getCleanupObjects() []client.Object {
+	objectMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: m.owner.GetNamespace()}
+	}
+	return []client.Object{
+		&appsv1.Deployment{ObjectMeta: objectMeta(resourcePrefix + m.owner.GetName())},
+		&appsv1.StatefulSet{ObjectMeta: objectMeta(resourcePrefix + m.owner.GetName())},
+		&corev1.Service{ObjectMeta: objectMeta(m.getAPIServiceName())},
+		&corev1.Service{ObjectMeta: objectMeta(m.getDataServiceName())},
+		&corev1.Service{ObjectMeta: objectMeta(m.getPeerServiceName())},
+		&networkingv1.NetworkPolicy{ObjectMeta: objectMeta(resourcePrefix + m.owner.GetName())},
+		&corev1.ConfigMap{ObjectMeta: objectMeta(m.getRenderedConfigName())},
+	}
+}
+
 // configureSyncthingAPIClient Configures the Syncthing API client if it has not been configured yet.
 func (m *Mover) configureSyncthingAPIClient(
 	apiSecret *corev1.Secret,
@@ -661,8 +1880,13 @@ func (m *Mover) configureSyncthingAPIClient(
 		m.apiConfig.APIURL = m.getAPIServiceAddress()
 	}
 
+	// the API may be served under a path prefix, e.g. by a reverse-proxy
+	if m.apiPathPrefix != nil {
+		m.apiConfig.APIPathPrefix = *m.apiPathPrefix
+	}
+
 	// configure authentication per request
-	m.apiConfig.APIKey = string(apiSecret.Data[apiKeyDataKey])
+	m.apiConfig.APIKey = string(apiSecret.Data[m.apiKeySecretKey])
 	clientConfig, err := m.loadTLSConfigFromSecret(apiSecret)
 	if err != nil {
 		return err
@@ -692,36 +1916,134 @@ func (m *Mover) validatePeerList() error {
 	return nil
 }
 
+// validateSysctls Checks that the sysctls set on the mover's pod are namespaced, as sysctls
+// affecting the whole node can't be set by a pod, and errors if any aren't.
+func (m *Mover) validateSysctls() error {
+	for _, sysctl := range m.sysctls {
+		if !isNamespacedSysctl(sysctl.Name) {
+			return fmt.Errorf("sysctl %s is not namespaced and can't be set on the mover", sysctl.Name)
+		}
+	}
+	return nil
+}
+
+// validatePeersFor Reports the problems found with the peer list for the Syncthing instance with the
+// given ID, returning false when the list can't be applied.
+func (m *Mover) validatePeersFor(myID string) bool {
+	m.reportSelfPeer(myID)
+	m.reportNoPeers(myID)
+	return m.reportTooManyPeers()
+}
+
+// reportTooManyPeers Sets the TooManyPeers condition while the peer list exceeds the maximum this
+// instance is allowed to handle, in which case the list isn't applied and false is returned. The
+// reconcile carries on, so that the mover keeps running with its current peers.
+func (m *Mover) reportTooManyPeers() bool {
+	if m.maxPeers == nil || len(m.peerList) <= int(*m.maxPeers) {
+		apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionTooManyPeers)
+		return true
+	}
+	apimeta.SetStatusCondition(m.conditions, metav1.Condition{
+		Type:   volsyncv1alpha1.ConditionTooManyPeers,
+		Status: metav1.ConditionTrue,
+		Reason: volsyncv1alpha1.TooManyPeersReasonExceeded,
+		Message: fmt.Sprintf("the peer list contains %d peers, exceeding the maximum of %d",
+			len(m.peerList), *m.maxPeers),
+	})
+	return false
+}
+
+// reportSelfPeer Sets the SelfPeerConfigured condition when the peer list contains the node itself,
+// which is ignored when configuring the devices, and warns about it when the entry first appears.
+// The condition is removed once the entry is gone.
+func (m *Mover) reportSelfPeer(myID string) {
+	for _, peer := range m.peerList {
+		if peer.ID != myID {
+			continue
+		}
+		if !apimeta.IsStatusConditionTrue(*m.conditions, volsyncv1alpha1.ConditionSelfPeerConfigured) {
+			m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeWarning,
+				volsyncv1alpha1.EvRSelfPeer, volsyncv1alpha1.EvANone,
+				"the peer list contains the node's own ID %s, ignoring it", myID)
+		}
+		apimeta.SetStatusCondition(m.conditions, metav1.Condition{
+			Type:    volsyncv1alpha1.ConditionSelfPeerConfigured,
+			Status:  metav1.ConditionTrue,
+			Reason:  volsyncv1alpha1.SelfPeerReasonIgnored,
+			Message: fmt.Sprintf("peer %s is the node itself and is ignored", myID),
+		})
+		return
+	}
+	apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionSelfPeerConfigured)
+}
+
+// reportNoPeers Sets the informational NoPeersConfigured condition while the peer list doesn't contain
+// any peer besides the node itself, as nothing is synced until a peer is added. The Services are still
+// created, as peers need the address reported in the status to add this instance.
+func (m *Mover) reportNoPeers(myID string) {
+	for _, peer := range m.peerList {
+		if peer.ID != myID {
+			apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionNoPeersConfigured)
+			return
+		}
+	}
+	apimeta.SetStatusCondition(m.conditions, metav1.Condition{
+		Type:    volsyncv1alpha1.ConditionNoPeersConfigured,
+		Status:  metav1.ConditionTrue,
+		Reason:  volsyncv1alpha1.NoPeersReasonEmptyList,
+		Message: "no peers are configured, so no data is being synced",
+	})
+}
+
 // ensureIsConfigured Takes the given syncthing state and updates it with the necessary information
 // from the peerList as well as the given apiSecret. An error is returned when we are unsuccessful in
 // updating the configuration.
 //
 // If there is no User/Password set on the object, or a user is set but doesn't match the value in the secret,
 // then ensureIsConfigured will update the Syncthing state to match the values in the secret.
-func (m *Mover) ensureIsConfigured(apiSecret *corev1.Secret, syncthing *api.Syncthing) error {
+func (m *Mover) ensureIsConfigured(ctx context.Context, apiSecret *corev1.Secret,
+	syncthing *api.Syncthing) error {
 	// nil check
 	if apiSecret == nil || syncthing == nil {
 		return fmt.Errorf("arguments cannot be nil")
 	}
 
-	m.logger.V(4).Info("Syncthing config", "config", syncthing.Configuration)
+	m.logger.V(4).Info("Syncthing config", "config", redactSyncthingConfig(&syncthing.Configuration))
 
-	// make sure that the spec isn't adding itself as a peer
-	for _, peer := range m.peerList {
-		if peer.ID == syncthing.MyID() {
-			return fmt.Errorf("the peer list contains the node itself")
-		}
+	if !m.validatePeersFor(syncthing.MyID()) {
+		m.logger.Info("the peer list exceeds the maximum number of peers, leaving Syncthing unchanged")
+		return nil
+	}
+
+	// the folder holding the data may have been removed from the config, e.g. through the web UI
+	var changes []string
+	if m.manageFolders && ensureManagedFolder(syncthing) {
+		m.logger.Info("the managed folder is missing, recreating it", "folder", managedFolderID)
+		changes = append(changes, "recreated the managed folder")
 	}
 
 	// check if the syncthing is configured
-	hasChanged := false
 	if syncthingNeedsReconfigure(m.peerList, syncthing) {
 		m.logger.V(4).Info("devices need to be reconfigured")
-		// configure the syncthing state with the new devices
-		if err := updateSyncthingDevices(m.peerList, syncthing); err != nil {
+		// configure the syncthing state with the new devices, and share the folders with them
+		// unless the folders are managed externally
+		updateDevices := updateSyncthingDevices
+		if !m.manageFolders {
+			updateDevices = setSyncthingDevices
+		}
+		if err := updateDevices(m.peerList, syncthing); err != nil {
 			return err
 		}
-		hasChanged = true
+		changes = append(changes, "reconfigured the devices")
+	}
+
+	// apply the folder & global options from the spec
+	optionsChanged, err := m.applySpecOptions(syncthing)
+	if err != nil {
+		return err
+	}
+	if optionsChanged {
+		changes = append(changes, "applied the options")
 	}
 
 	// set the user and password if not already set
@@ -730,40 +2052,430 @@ func (m *Mover) ensureIsConfigured(apiSecret *corev1.Secret, syncthing *api.Sync
 		m.logger.Info("setting user and password")
 		syncthing.Configuration.GUI.User = string(apiSecret.Data[usernameDataKey])
 		syncthing.Configuration.GUI.Password = string(apiSecret.Data[passwordDataKey])
-		hasChanged = true
+		changes = append(changes, "set the user and password")
 	}
 
 	// update the config
-	if hasChanged {
-		// get syncthing object & update the remote config w/ it
-		m.logger.Info("syncthing needs to be updated")
-		m.logger.V(4).Info("updating with config", "config", syncthing.Configuration)
-		err := m.syncthingConnection.PublishConfig(syncthing.Configuration)
+	if len(changes) > 0 {
+		return m.publishConfig(ctx, syncthing.Configuration, changes)
+	}
+	apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionConfigNotPersisting)
+	return nil
+}
+
+// publishConfig Updates Syncthing's config and reads it back. Syncthing may accept a config that it
+// can't persist, e.g. when its config volume has been remounted read-only, in which case the
+// ConfigNotPersisting condition is set, and further updates are held off for a while rather than
+// reconfiguring Syncthing on every reconcile. The given changes are reported through an event once
+// the config has been published.
+func (m *Mover) publishConfig(ctx context.Context, conf config.Configuration, changes []string) error {
+	notPersisting := apimeta.FindStatusCondition(*m.conditions, volsyncv1alpha1.ConditionConfigNotPersisting)
+	if notPersisting != nil && notPersisting.Status == metav1.ConditionTrue &&
+		m.clock.Since(notPersisting.LastTransitionTime.Time) < configNotPersistingRetryInterval {
+		m.logger.V(1).Info("syncthing isn't persisting its config, holding off on updating it")
+		return nil
+	}
+
+	// get syncthing object & update the remote config w/ it
+	m.logger.Info("syncthing needs to be updated")
+	m.logger.V(4).Info("updating with config", "config", redactSyncthingConfig(&conf))
+	if err := m.syncthingConnection.PublishConfig(ctx, conf); err != nil {
+		m.logger.Error(err, "error updating syncthing config")
+		return m.reportAPIError(err, "update the Syncthing config")
+	}
+	m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeNormal,
+		volsyncv1alpha1.EvRSyncthingConfig, volsyncv1alpha1.EvANone,
+		"updated the Syncthing config: %s", strings.Join(changes, ", "))
+
+	readBack, err := m.syncthingConnection.FetchConfig(ctx)
+	if err != nil {
+		return m.reportAPIError(err, "read back the Syncthing config")
+	}
+	if configPersisted(&conf, readBack) {
+		apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionConfigNotPersisting)
+		return nil
+	}
+	m.logger.Info("syncthing did not persist its config, holding off on updating it",
+		"retryAfter", configNotPersistingRetryInterval)
+	// the condition is replaced, so that its transition time starts the hold off anew
+	apimeta.RemoveStatusCondition(m.conditions, volsyncv1alpha1.ConditionConfigNotPersisting)
+	apimeta.SetStatusCondition(m.conditions, metav1.Condition{
+		Type:               volsyncv1alpha1.ConditionConfigNotPersisting,
+		Status:             metav1.ConditionTrue,
+		Reason:             volsyncv1alpha1.ConfigNotPersistingReasonDiffer,
+		LastTransitionTime: metav1.NewTime(m.clock.Now()),
+		Message: "the config read back from Syncthing differs from the one written, " +
+			"its config volume may be read-only",
+	})
+	return nil
+}
+
+// reportAPIError Warns about a request to the Syncthing API which failed, returning the error. The API
+// refusing connections while Syncthing is starting isn't worth a warning.
+func (m *Mover) reportAPIError(err error, action string) error {
+	if api.IsUnavailable(err) {
+		return err
+	}
+	m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeWarning,
+		volsyncv1alpha1.EvRSyncthingAPI, volsyncv1alpha1.EvANone,
+		"unable to %s: %v", action, err)
+	return err
+}
+
+// getRenderedConfigName Returns the name of the ConfigMap holding the rendered Syncthing config.
+func (m *Mover) getRenderedConfigName() string {
+	return resourcePrefix + m.owner.GetName() + "-rendered-config"
+}
+
+// ensureRenderedConfig Renders the devices and folders configured in Syncthing into a ConfigMap
+// for review, or removes the ConfigMap once rendering has been disabled.
+func (m *Mover) ensureRenderedConfig(ctx context.Context, syncthingConfig *config.Configuration) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.getRenderedConfigName(),
+			Namespace: m.owner.GetNamespace(),
+		},
+	}
+	logger := m.logger.WithValues("configMap", client.ObjectKeyFromObject(configMap))
+
+	if !m.renderConfig {
+		err := m.client.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)
+		if errors.IsNotFound(err) || (err == nil && !metav1.IsControlledBy(configMap, m.owner)) {
+			return nil
+		}
+		if err == nil {
+			err = m.client.Delete(ctx, configMap)
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	rendered, err := renderSyncthingConfig(syncthingConfig)
+	if err != nil {
+		return err
+	}
+	_, err = m.createOrUpdate(ctx, configMap, func() error {
+		if err := ctrl.SetControllerReference(m.owner, configMap, m.client.Scheme()); err != nil {
+			logger.Error(err, utils.ErrUnableToSetControllerRef)
+			return err
+		}
+		utils.SetOwnedByVolSync(configMap)
+		m.setOwnerUIDAnnotation(configMap)
+		configMap.Data = map[string]string{renderedConfigDataKey: rendered}
+		return nil
+	})
+	return err
+}
+
+// ensureStatsSnapshots Writes a snapshot of the peer & folder statistics in the status to a new ConfigMap
+// whenever the interval has passed since the latest one, and prunes the oldest snapshots past the
+// retained count. All of the snapshots are removed once they have been disabled.
+func (m *Mover) ensureStatsSnapshots(ctx context.Context) error {
+	snapshots, err := m.listStatsSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+
+	retain := 0
+	if m.statsSnapshots != nil {
+		retain = m.getStatsSnapshotRetain()
+		now := m.clock.Now()
+		if len(snapshots) == 0 ||
+			!now.Before(getStatsSnapshotTime(snapshots[len(snapshots)-1]).Add(m.getStatsSnapshotInterval())) {
+			snapshot, err := m.writeStatsSnapshot(ctx, now)
+			if err != nil {
+				return err
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	for len(snapshots) > retain {
+		if err := m.client.Delete(ctx, snapshots[0]); client.IgnoreNotFound(err) != nil {
+			m.logger.Error(err, "error pruning a stats snapshot", "configMap", client.ObjectKeyFromObject(snapshots[0]))
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// listStatsSnapshots Returns the ConfigMaps holding the stats snapshots of the owner, oldest first.
+func (m *Mover) listStatsSnapshots(ctx context.Context) ([]*corev1.ConfigMap, error) {
+	configMaps := &corev1.ConfigMapList{}
+	if err := m.client.List(ctx, configMaps, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels{statsSnapshotLabel: string(m.owner.GetUID())}); err != nil {
+		return nil, err
+	}
+
+	snapshots := []*corev1.ConfigMap{}
+	for i := range configMaps.Items {
+		if metav1.IsControlledBy(&configMaps.Items[i], m.owner) {
+			snapshots = append(snapshots, &configMaps.Items[i])
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return getStatsSnapshotTime(snapshots[i]).Before(getStatsSnapshotTime(snapshots[j]))
+	})
+	return snapshots, nil
+}
+
+// writeStatsSnapshot Creates a ConfigMap holding the peer & folder statistics currently in the status.
+func (m *Mover) writeStatsSnapshot(ctx context.Context, takenAt time.Time) (*corev1.ConfigMap, error) {
+	stats, err := json.MarshalIndent(struct {
+		Peers   []volsyncv1alpha1.SyncthingPeerStatus   `json:"peers"`
+		Folders []volsyncv1alpha1.SyncthingFolderStatus `json:"folders"`
+	}{
+		Peers:   m.status.Peers,
+		Folders: m.status.Folders,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	takenAt = takenAt.UTC()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        resourcePrefix + m.owner.GetName() + "-stats-" + takenAt.Format("20060102-150405"),
+			Namespace:   m.owner.GetNamespace(),
+			Labels:      map[string]string{statsSnapshotLabel: string(m.owner.GetUID())},
+			Annotations: map[string]string{statsTakenAtAnnotation: takenAt.Format(time.RFC3339)},
+		},
+		Data: map[string]string{statsSnapshotDataKey: string(stats)},
+	}
+	logger := m.logger.WithValues("configMap", client.ObjectKeyFromObject(configMap))
+	if err := ctrl.SetControllerReference(m.owner, configMap, m.client.Scheme()); err != nil {
+		logger.Error(err, utils.ErrUnableToSetControllerRef)
+		return nil, err
+	}
+	utils.SetOwnedByVolSync(configMap)
+	m.setOwnerUIDAnnotation(configMap)
+	if err := m.client.Create(ctx, configMap); err != nil {
+		logger.Error(err, "error writing a stats snapshot")
+		return nil, err
+	}
+	return configMap, nil
+}
+
+// getStatsSnapshotTime Returns when the given stats snapshot was taken, or the zero time
+// when it can't be determined, so that the snapshot is considered the oldest.
+func getStatsSnapshotTime(snapshot *corev1.ConfigMap) time.Time {
+	takenAt, err := time.Parse(time.RFC3339, snapshot.Annotations[statsTakenAtAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return takenAt
+}
+
+// ensureIndexSnapshots Takes a VolumeSnapshot of the volume holding Syncthing's index whenever the
+// interval has passed since the latest one, and prunes the oldest snapshots past the retained count.
+// The snapshots are kept once they are no longer requested, as they may hold the only healthy copy of
+// the index, and are removed along with the ReplicationSource.
+func (m *Mover) ensureIndexSnapshots(ctx context.Context) error {
+	if m.indexSnapshots == nil {
+		return nil
+	}
+	snapshots, err := m.listIndexSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := m.clock.Now()
+	if len(snapshots) == 0 ||
+		!now.Before(getIndexSnapshotTime(snapshots[len(snapshots)-1]).Add(m.getIndexSnapshotInterval())) {
+		snapshot, err := m.takeIndexSnapshot(ctx, now)
 		if err != nil {
-			m.logger.Error(err, "error updating syncthing config")
 			return err
 		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	for len(snapshots) > m.getIndexSnapshotRetain() {
+		if err := m.client.Delete(ctx, snapshots[0]); client.IgnoreNotFound(err) != nil {
+			m.logger.Error(err, "error pruning an index snapshot",
+				"volumeSnapshot", client.ObjectKeyFromObject(snapshots[0]))
+			return err
+		}
+		snapshots = snapshots[1:]
 	}
 	return nil
 }
 
+// listIndexSnapshots Returns the VolumeSnapshots of the owner's index, oldest first.
+func (m *Mover) listIndexSnapshots(ctx context.Context) ([]*snapv1.VolumeSnapshot, error) {
+	volumeSnapshots := &snapv1.VolumeSnapshotList{}
+	if err := m.client.List(ctx, volumeSnapshots, client.InNamespace(m.owner.GetNamespace()),
+		client.MatchingLabels{indexSnapshotLabel: string(m.owner.GetUID())}); err != nil {
+		return nil, err
+	}
+
+	snapshots := []*snapv1.VolumeSnapshot{}
+	for i := range volumeSnapshots.Items {
+		if metav1.IsControlledBy(&volumeSnapshots.Items[i], m.owner) {
+			snapshots = append(snapshots, &volumeSnapshots.Items[i])
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return getIndexSnapshotTime(snapshots[i]).Before(getIndexSnapshotTime(snapshots[j]))
+	})
+	return snapshots, nil
+}
+
+// takeIndexSnapshot Creates a VolumeSnapshot of the PVC holding Syncthing's config and index. The
+// snapshot is crash-consistent, which Syncthing's database recovers from like from a power loss.
+func (m *Mover) takeIndexSnapshot(ctx context.Context, takenAt time.Time) (*snapv1.VolumeSnapshot, error) {
+	takenAt = takenAt.UTC()
+	configPVCName := m.getConfigPVCName()
+	snapshot := &snapv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        resourcePrefix + m.owner.GetName() + "-index-" + takenAt.Format("20060102-150405"),
+			Namespace:   m.owner.GetNamespace(),
+			Labels:      map[string]string{indexSnapshotLabel: string(m.owner.GetUID())},
+			Annotations: map[string]string{indexTakenAtAnnotation: takenAt.Format(time.RFC3339)},
+		},
+		Spec: snapv1.VolumeSnapshotSpec{
+			Source: snapv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &configPVCName,
+			},
+			VolumeSnapshotClassName: m.indexSnapshots.VolumeSnapshotClassName,
+		},
+	}
+	logger := m.logger.WithValues("volumeSnapshot", client.ObjectKeyFromObject(snapshot))
+	if err := ctrl.SetControllerReference(m.owner, snapshot, m.client.Scheme()); err != nil {
+		logger.Error(err, utils.ErrUnableToSetControllerRef)
+		return nil, err
+	}
+	utils.SetOwnedByVolSync(snapshot)
+	m.setOwnerUIDAnnotation(snapshot)
+	if err := m.client.Create(ctx, snapshot); err != nil {
+		logger.Error(err, "error taking a snapshot of the index")
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// getIndexSnapshotTime Returns when the given index snapshot was taken, or the zero time when it
+// can't be determined, so that the snapshot is considered the oldest.
+func getIndexSnapshotTime(snapshot *snapv1.VolumeSnapshot) time.Time {
+	takenAt, err := time.Parse(time.RFC3339, snapshot.Annotations[indexTakenAtAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return takenAt
+}
+
+// applySpecOptions Applies the folder and global options, and the name of the local device, provided in
+// the spec to the given Syncthing config, and returns 'true' if the config was changed as a result.
+func (m *Mover) applySpecOptions(syncthing *api.Syncthing) (bool, error) {
+	if err := validateFolderSpec(m.folder); err != nil {
+		return false, err
+	}
+
+	hasChanged := false
+	if m.manageFolders && updateSyncthingFolders(m.folder, syncthing) {
+		m.logger.V(4).Info("folders need to be reconfigured")
+		hasChanged = true
+	}
+	// changing ownership requires the CHOWN capability, which is only granted to privileged movers.
+	// The warning is repeated while the options are set, the recorder folding the repeats together.
+	if m.manageFolders && changesOwnership(m.folder) && !m.privileged {
+		m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeWarning,
+			volsyncv1alpha1.EvRMoverNotPriv, volsyncv1alpha1.EvANone,
+			"folder.copyOwnershipFromParent or folder.syncOwnership is set, but the mover is not "+
+				"privileged and can't change the ownership of files")
+	}
+
+	// Syncthing may rename itself, e.g. after the hostname of the mover's pod
+	if m.localDeviceName != nil && updateLocalDeviceName(*m.localDeviceName, syncthing) {
+		m.logger.V(4).Info("the local device needs to be renamed")
+		hasChanged = true
+	}
+
+	// the passwords are applied once the folders are shared with the peers, and only when a Secret holds them
+	if m.manageFolders && m.encryptionSecretName != nil &&
+		updateFolderEncryptionPasswords(m.encryptionPasswords, syncthing) {
+		m.logger.V(4).Info("folder encryption passwords need to be reconfigured")
+		hasChanged = true
+	}
+
+	optionsChanged, err := updateSyncthingOptions(m.options, syncthing)
+	if err != nil {
+		return false, err
+	}
+	if optionsChanged {
+		m.logger.V(4).Info("options need to be reconfigured")
+		hasChanged = true
+	}
+	return hasChanged, nil
+}
+
 // ensureStatusIsUpdated Updates the mover's status to be reported by the ReplicationSource object.
 func (m *Mover) ensureStatusIsUpdated(dataSVC *corev1.Service,
 	syncthing *api.Syncthing) error {
+	// the peers' connections are only known when Syncthing's state could be fetched
+	if syncthing == nil {
+		return fmt.Errorf("the state of Syncthing is unknown")
+	}
+
 	// fail until we can get the address
-	addr, err := m.GetDataServiceAddress(dataSVC)
+	addr, err := m.getAdvertisedAddress(dataSVC)
 	if err != nil {
 		return err
 	}
 
 	// set syncthing-related info
 	m.status.Address = asTCPAddress(addr)
+	m.status.DataScheme, m.status.DataHost, m.status.DataPort, err = splitSyncthingAddress(m.status.Address)
+	if err != nil {
+		return err
+	}
 	m.status.ID = syncthing.MyID()
+	m.status.ConfigVersion = int32(syncthing.Configuration.Version)
+	previousPeers := m.status.Peers
+	var sinceObserved time.Duration
+	if m.status.PeersObservedTime != nil {
+		sinceObserved = m.clock.Since(m.status.PeersObservedTime.Time)
+	}
 	m.status.Peers = m.getConnectedPeers(syncthing)
+	m.warnAboutStalePeers(previousPeers)
+	m.accumulatePeerUptime(previousPeers)
+	// the ETAs follow the current rate, rather than the average over the connections' lifetime
+	transferRate := getTransferRate(previousPeers, m.status.Peers, sinceObserved)
+	m.status.Folders = getFolderStatuses(syncthing, m.status.Folders, transferRate)
+	m.status.FoldersInError = countFoldersInError(syncthing, m.status.Folders)
+	previousIndexBytes := m.status.EstimatedIndexBytes
+	m.status.EstimatedIndexBytes = estimateIndexSize(m.status.Folders)
+	m.warnAboutIndexSize(previousIndexBytes)
 
 	return nil
 }
 
+// fetchFolderDetails Pulls the status and contents of the folders, which are only needed to report their
+// status. A folder whose status or contents can't be pulled is left out, and keeps what was previously
+// reported.
+func (m *Mover) fetchFolderDetails(ctx context.Context, syncthing *api.Syncthing) {
+	if syncthing.FolderEntries == nil {
+		syncthing.FolderEntries = map[string][]api.FileEntry{}
+	}
+	if syncthing.FolderStatuses == nil {
+		syncthing.FolderStatuses = map[string]api.FolderStatus{}
+	}
+	for _, folder := range syncthing.Configuration.Folders {
+		status, err := m.syncthingConnection.FetchFolderStatus(ctx, folder.ID)
+		if err != nil {
+			m.logger.Error(err, "unable to fetch the status of the folder", "folder", folder.ID)
+		} else {
+			syncthing.FolderStatuses[folder.ID] = *status
+		}
+
+		entries, err := m.syncthingConnection.FetchFolderEntries(ctx, folder.ID, folderBrowseLevels)
+		if err != nil {
+			m.logger.Error(err, "unable to fetch the contents of the folder", "folder", folder.ID)
+			continue
+		}
+		syncthing.FolderEntries[folder.ID] = entries
+	}
+}
+
 // getConnectedPeers Retrieves a list of all the peers connected to our Syncthing instance.
 func (m *Mover) getConnectedPeers(syncthing *api.Syncthing) []volsyncv1alpha1.SyncthingPeerStatus {
 	connectedPeers := []volsyncv1alpha1.SyncthingPeerStatus{}
@@ -798,23 +2510,157 @@ func (m *Mover) getConnectedPeers(syncthing *api.Syncthing) []volsyncv1alpha1.Sy
 		deviceName := device.Name
 
 		// check connection status
+		lastSeen := getLastSeen(syncthing.DeviceStats[deviceID])
 		connectedPeers = append(connectedPeers, volsyncv1alpha1.SyncthingPeerStatus{
-			ID:           deviceID,
-			Address:      tcpAddress,
-			Connected:    connectionInfo.Connected,
-			Name:         deviceName,
-			IntroducedBy: introducedBy.GoString(),
+			ID:            deviceID,
+			Address:       tcpAddress,
+			Connected:     connectionInfo.Connected,
+			Name:          deviceName,
+			IntroducedBy:  introducedBy.GoString(),
+			LastSeen:      lastSeen,
+			Stale:         m.isPeerStale(connectionInfo.Connected, lastSeen),
+			InBytesTotal:  int64(connectionInfo.InBytesTotal),
+			OutBytesTotal: int64(connectionInfo.OutBytesTotal),
 		})
 	}
 	return connectedPeers
 }
 
+// checkPeersAreReachable Dials the address of each peer in the status when requested, and records
+// whether it was reachable. Peers without a TCP address, e.g. those found through discovery, aren't checked.
+// The peers are dialed concurrently, and those which couldn't be reached within peerReachabilityTimeout
+// are reported as unreachable.
+func (m *Mover) checkPeersAreReachable(ctx context.Context) {
+	if !m.checkPeerReachability {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, peerReachabilityTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	addresses := map[string]string{}
+	for _, peer := range m.peerList {
+		addresses[peer.ID] = peer.Address
+	}
+	for i := range m.status.Peers {
+		peer := &m.status.Peers[i]
+		// introduced peers aren't in the peer list, so the address of their connection is used
+		address, found := addresses[peer.ID]
+		if !found {
+			address = peer.Address
+		}
+		scheme, host, port, err := splitSyncthingAddress(address)
+		if err != nil || !strings.HasPrefix(scheme, "tcp") || host == "" || port == 0 {
+			continue
+		}
+		wg.Add(1)
+		// each dial only writes to the status of its own peer
+		go func(peer *volsyncv1alpha1.SyncthingPeerStatus, address string, hostPort string) {
+			defer wg.Done()
+			conn, err := m.dialer.DialContext(ctx, "tcp", hostPort)
+			reachable := err == nil
+			if reachable {
+				_ = conn.Close()
+			} else {
+				m.logger.V(1).Info("peer is unreachable", "peer", peer.ID, "address", address, "error", err.Error())
+			}
+			peer.Reachable = &reachable
+		}(peer, address, net.JoinHostPort(host, strconv.Itoa(int(port))))
+	}
+	wg.Wait()
+}
+
+// isPeerStale Determines whether a peer has gone unseen for longer than the stalePeerThreshold.
+// Peers which are connected, or have never been seen, aren't considered stale.
+func (m *Mover) isPeerStale(connected bool, lastSeen *metav1.Time) bool {
+	if m.stalePeerThreshold == nil || connected || lastSeen == nil {
+		return false
+	}
+	return m.clock.Since(lastSeen.Time) > m.stalePeerThreshold.Duration
+}
+
+// accumulatePeerUptime Adds the time elapsed since the previous status update to the connected
+// duration of each peer which remained connected throughout it.
+func (m *Mover) accumulatePeerUptime(previousPeers []volsyncv1alpha1.SyncthingPeerStatus) {
+	now := metav1.NewTime(m.clock.Now())
+	var elapsed time.Duration
+	if m.status.PeersObservedTime != nil {
+		elapsed = now.Sub(m.status.PeersObservedTime.Time)
+	}
+	previous := map[string]volsyncv1alpha1.SyncthingPeerStatus{}
+	for _, peer := range previousPeers {
+		previous[peer.ID] = peer
+	}
+	for i := range m.status.Peers {
+		peer := &m.status.Peers[i]
+		uptime := time.Duration(0)
+		if prev, ok := previous[peer.ID]; ok {
+			if prev.ConnectedDuration != nil {
+				uptime = prev.ConnectedDuration.Duration
+			}
+			if prev.Connected && peer.Connected && elapsed > 0 {
+				uptime += elapsed
+			}
+		}
+		peer.ConnectedDuration = &metav1.Duration{Duration: uptime}
+	}
+	m.status.PeersObservedTime = &now
+}
+
+// warnAboutStalePeers Emits a warning event for each of the peers which became stale
+// since the given previous peer statuses were reported.
+func (m *Mover) warnAboutStalePeers(previousPeers []volsyncv1alpha1.SyncthingPeerStatus) {
+	wasStale := map[string]bool{}
+	for _, peer := range previousPeers {
+		wasStale[peer.ID] = peer.Stale
+	}
+	for _, peer := range m.status.Peers {
+		if peer.Stale && !wasStale[peer.ID] {
+			m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeWarning,
+				volsyncv1alpha1.EvRPeerStale, volsyncv1alpha1.EvANone,
+				"peer %s has not been seen since %s", peer.ID, peer.LastSeen.Format(time.RFC3339))
+		}
+	}
+}
+
+// warnAboutIndexSize Emits a warning event when the estimated size of the index database grew past
+// the maximum since the given previous estimate was reported.
+func (m *Mover) warnAboutIndexSize(previousIndexBytes int64) {
+	if m.maxIndexSize == nil {
+		return
+	}
+	maxIndexBytes := m.maxIndexSize.Value()
+	if m.status.EstimatedIndexBytes > maxIndexBytes && previousIndexBytes <= maxIndexBytes {
+		m.eventRecorder.Eventf(m.owner, nil, corev1.EventTypeWarning,
+			volsyncv1alpha1.EvRIndexTooLarge, volsyncv1alpha1.EvANone,
+			"the index database is estimated at %s, exceeding the maximum of %s; consider increasing "+
+				"configCapacity or enabling autoSizeConfig before the config volume fills up",
+			resource.NewQuantity(m.status.EstimatedIndexBytes, resource.BinarySI), m.maxIndexSize)
+	}
+}
+
 // getAPIServiceName Returns the name of the API service exposing the Syncthing API.
 func (m *Mover) getAPIServiceName() string {
 	serviceName := resourcePrefix + m.owner.GetName() + "-api"
 	return serviceName
 }
 
+// getDataServiceName Returns the name of the Service exposing the Syncthing data port.
+func (m *Mover) getDataServiceName() string {
+	return resourcePrefix + m.owner.GetName() + "-data"
+}
+
+// getPeerServiceName Returns the name of the headless Service used when running as a StatefulSet.
+func (m *Mover) getPeerServiceName() string {
+	return resourcePrefix + m.owner.GetName() + "-peer"
+}
+
+// getPeerPodDNS Returns the stable DNS name of the StatefulSet's pod, through the headless Service.
+func (m *Mover) getPeerPodDNS() string {
+	return fmt.Sprintf("%s%s-0.%s.%s", resourcePrefix, m.owner.GetName(), m.getPeerServiceName(),
+		m.owner.GetNamespace())
+}
+
 // getAPIServiceDNS Returns the DNS of the service exposing the Syncthing API, formatted as ClusterDNS.
 func (m *Mover) getAPIServiceDNS() string {
 	serviceName := m.getAPIServiceName()
@@ -822,6 +2668,8 @@ func (m *Mover) getAPIServiceDNS() string {
 }
 
 // getAPIServiceAddress Returns a ClusterDNS address of the service exposing the Syncthing API.
+// The Service's name is resolved on every request rather than pinning its IP, and the mover is rebuilt on every
+// reconcile, so the address stays valid when the Service is recreated with a new ClusterIP.
 func (m *Mover) getAPIServiceAddress() string {
 	serviceDNS := m.getAPIServiceDNS()
 	return fmt.Sprintf("https://%s:%d", serviceDNS, apiPort)
@@ -829,21 +2677,34 @@ func (m *Mover) getAPIServiceAddress() string {
 
 // loadTLSConfigFromSecret loads the TLS config from the given secret.
 func (m *Mover) loadTLSConfigFromSecret(apiSecret *corev1.Secret) (*tls.Config, error) {
+	// a certificate provided by the user must be valid for the API Service's DNS name
+	if m.apiCertPEM != nil {
+		return newTLSConfigTrusting(m.apiCertPEM, m.getAPIServiceDNS()), nil
+	}
+
 	// grab the server cert from the secret
 	serverCert, ok := apiSecret.Data[httpsCertDataKey]
 	if !ok {
 		return nil, fmt.Errorf("could not find the server cert in the secret")
 	}
 
+	// the self-signed certificate is pinned, as it may have been issued for a name other than the
+	// DNS name the API Service is reached through, e.g. after the Service was renamed
+	return api.PinnedTLSConfig(serverCert, m.getAPIServiceDNS()), nil
+}
+
+// newTLSConfigTrusting Returns a TLS config which trusts the given PEM-encoded certificates, when they
+// are valid for the given server name.
+func newTLSConfigTrusting(serverCert []byte, serverName string) *tls.Config {
 	// create the CA CertPool
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(serverCert)
 
 	// create the TLS config
-	conf := &tls.Config{
+	return &tls.Config{
 		// require at least TLS1.2
 		MinVersion: tls.VersionTLS12,
 		RootCAs:    caCertPool,
+		ServerName: serverName,
 	}
-	return conf, nil
 }
@@ -60,6 +60,8 @@ var _ = BeforeSuite(func() {
 		CRDDirectoryPaths: []string{
 			// VolSync CRDs
 			filepath.Join("..", "..", "..", "config", "crd", "bases"),
+			// Snapshot CRDs
+			filepath.Join("..", "..", "..", "hack", "crds"),
 		},
 		ErrorIfCRDPathMissing: true,
 	}
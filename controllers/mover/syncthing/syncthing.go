@@ -18,18 +18,64 @@ package syncthing
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"path"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/backube/volsync/api/v1alpha1"
 	"github.com/backube/volsync/controllers/mover/syncthing/api"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/protocol"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errorsutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
-// updateSyncthingDevices Updates the Syncthing's connected devices with the provided peerList.
-// An error may be encountered when reading the DeviceID from a string.
+// supportedFilesystemTypes Maps the filesystem types which may back a folder onto Syncthing's types.
+var supportedFilesystemTypes = map[string]fs.FilesystemType{
+	"":      fs.FilesystemTypeBasic,
+	"basic": fs.FilesystemTypeBasic,
+	"fake":  fs.FilesystemTypeFake,
+}
+
+// supportedFolderTypes Maps the types of folder which may be requested onto Syncthing's types.
+var supportedFolderTypes = map[string]config.FolderType{
+	"":            config.FolderTypeSendReceive,
+	"sendreceive": config.FolderTypeSendReceive,
+	"sendonly":    config.FolderTypeSendOnly,
+	"receiveonly": config.FolderTypeReceiveOnly,
+}
+
+// supportedVersioningTypes Lists the types of versioning Syncthing may be configured with. Versioning
+// through an external command isn't supported, as the command would have to be part of the mover's image.
+var supportedVersioningTypes = map[string]bool{
+	"trashcan":  true,
+	"simple":    true,
+	"staggered": true,
+}
+
+// updateSyncthingDevices Updates the Syncthing's connected devices with the provided peerList,
+// and shares the folders with them. An error may be encountered when reading the DeviceID from a string.
 func updateSyncthingDevices(peerList []v1alpha1.SyncthingPeer,
+	syncthing *api.Syncthing) error {
+	if err := setSyncthingDevices(peerList, syncthing); err != nil {
+		return err
+	}
+	syncthing.ShareFoldersWithDevices(syncthing.Configuration.Devices)
+	return nil
+}
+
+// setSyncthingDevices Replaces Syncthing's devices with the given peerList, keeping this node
+// and any introduced devices, without sharing any folders with them.
+func setSyncthingDevices(peerList []v1alpha1.SyncthingPeer,
 	syncthing *api.Syncthing) error {
 	if syncthing == nil {
 		return fmt.Errorf("syncthing cannot be nil")
@@ -43,38 +89,784 @@ func updateSyncthingDevices(peerList []v1alpha1.SyncthingPeer,
 	}
 	// Add the devices from the peerList to the device list
 	for _, device := range peerList {
-		deviceID, err := protocol.DeviceIDFromString(device.ID)
+		// avoid self
+		if device.ID == syncthing.MyID() {
+			continue
+		}
+		stDeviceToAdd, err := peerToDevice(device)
 		if err != nil {
 			return err
 		}
-		stDeviceToAdd := config.DeviceConfiguration{
-			DeviceID:   deviceID,
-			Addresses:  []string{device.Address},
-			Introducer: device.Introducer,
-		}
 		newDevices = append(newDevices, stDeviceToAdd)
 	}
 	syncthing.Configuration.Devices = newDevices
-	syncthing.ShareFoldersWithDevices(newDevices)
 	return nil
 }
 
+// updateLocalDeviceName Sets the name of this Syncthing instance's own device, and returns 'true'
+// if it was changed.
+func updateLocalDeviceName(name string, syncthing *api.Syncthing) bool {
+	for i := range syncthing.Configuration.Devices {
+		device := &syncthing.Configuration.Devices[i]
+		if device.DeviceID.GoString() == syncthing.MyID() && device.Name != name {
+			device.Name = name
+			return true
+		}
+	}
+	return false
+}
+
+// peerToDevice Converts the given peer into the Syncthing device configuration it describes.
+func peerToDevice(peer v1alpha1.SyncthingPeer) (config.DeviceConfiguration, error) {
+	deviceID, err := protocol.DeviceIDFromString(peer.ID)
+	if err != nil {
+		return config.DeviceConfiguration{}, err
+	}
+	// an empty compression is Syncthing's default of 'metadata'
+	var compression protocol.Compression
+	if err := compression.UnmarshalText([]byte(peer.Compression)); err != nil {
+		return config.DeviceConfiguration{}, err
+	}
+	return config.DeviceConfiguration{
+		DeviceID:    deviceID,
+		Addresses:   []string{peer.Address},
+		Introducer:  peer.Introducer,
+		Compression: compression,
+		Paused:      peer.Paused,
+		MaxSendKbps: int(peer.MaxSendKbps),
+		MaxRecvKbps: int(peer.MaxRecvKbps),
+	}, nil
+}
+
+// deviceDiffersFromPeer Returns 'true' if any of the options VolSync manages for the given
+// device differ from what is specified by the peer.
+func deviceDiffersFromPeer(device config.DeviceConfiguration, peer v1alpha1.SyncthingPeer) bool {
+	desired, err := peerToDevice(peer)
+	if err != nil {
+		// let the reconfiguration surface the error
+		return true
+	}
+	return len(device.Addresses) != 1 || device.Addresses[0] != desired.Addresses[0] ||
+		device.Introducer != desired.Introducer ||
+		device.Compression != desired.Compression ||
+		device.Paused != desired.Paused ||
+		device.MaxSendKbps != desired.MaxSendKbps ||
+		device.MaxRecvKbps != desired.MaxRecvKbps
+}
+
+// updateSyncthingFolders Applies the options from the given folder spec to the folders shared by Syncthing,
+// and returns 'true' if any of the folders were changed.
+func updateSyncthingFolders(folderSpec *v1alpha1.SyncthingFolderSpec, syncthing *api.Syncthing) bool {
+	// use the defaults when no options are provided
+	if folderSpec == nil {
+		folderSpec = &v1alpha1.SyncthingFolderSpec{}
+	}
+
+	label := folderSpec.Label
+	if label == "" {
+		label = defaultFolderLabel
+	}
+	markerName := folderSpec.MarkerName
+	if markerName == "" {
+		markerName = defaultFolderMarkerName
+	}
+	// unsupported types are rejected by validateFolderSpec beforehand
+	filesystemType := supportedFilesystemTypes[folderSpec.FilesystemType]
+	folderType := supportedFolderTypes[folderSpec.Type]
+
+	hasChanged := false
+	for i := range syncthing.Configuration.Folders {
+		folder := &syncthing.Configuration.Folders[i]
+		// only the label is changed, the folder ID must stay stable for peers
+		if folder.Label != label {
+			folder.Label = label
+			hasChanged = true
+		}
+		if folder.IgnoreDelete != folderSpec.IgnoreDelete {
+			folder.IgnoreDelete = folderSpec.IgnoreDelete
+			hasChanged = true
+		}
+		if folder.MarkerName != markerName {
+			folder.MarkerName = markerName
+			hasChanged = true
+		}
+		if updateFolderOwnership(folderSpec, folder) {
+			hasChanged = true
+		}
+		if folder.FilesystemType != filesystemType {
+			folder.FilesystemType = filesystemType
+			hasChanged = true
+		}
+		if folder.Type != folderType {
+			folder.Type = folderType
+			hasChanged = true
+		}
+		if updateFolderTuning(folderSpec, folder) {
+			hasChanged = true
+		}
+		if updateFolderXattrs(folderSpec, folder) {
+			hasChanged = true
+		}
+		if updateFolderVersioning(folderSpec.Versioning, folder) {
+			hasChanged = true
+		}
+	}
+	return hasChanged
+}
+
+// updateFolderEncryptionPasswords Sets the password each folder is encrypted with for the devices it's
+// shared with, keyed by the device's ID, and returns 'true' if any of the folders were changed.
+// Devices without a password are left untouched, keeping any password set through the GUI.
+func updateFolderEncryptionPasswords(passwords map[string]string, syncthing *api.Syncthing) bool {
+	hasChanged := false
+	for i := range syncthing.Configuration.Folders {
+		folder := &syncthing.Configuration.Folders[i]
+		for j := range folder.Devices {
+			device := &folder.Devices[j]
+			password, ok := passwords[device.DeviceID.GoString()]
+			if ok && device.EncryptionPassword != password {
+				device.EncryptionPassword = password
+				hasChanged = true
+			}
+		}
+	}
+	return hasChanged
+}
+
+// validateFolderSpec Returns the errors found with any of the given folder options, which can't be
+// applied by Syncthing.
+func validateFolderSpec(folderSpec *v1alpha1.SyncthingFolderSpec) error {
+	if folderSpec == nil {
+		return nil
+	}
+	errs := []error{}
+	if _, ok := supportedFilesystemTypes[folderSpec.FilesystemType]; !ok {
+		errs = append(errs, fmt.Errorf("unsupported folder filesystemType %q", folderSpec.FilesystemType))
+	}
+	if _, ok := supportedFolderTypes[folderSpec.Type]; !ok {
+		errs = append(errs, fmt.Errorf("unsupported folder type %q", folderSpec.Type))
+	}
+	if folderSpec.Versioning != nil && !supportedVersioningTypes[folderSpec.Versioning.Type] {
+		errs = append(errs, fmt.Errorf("unsupported folder versioning type %q", folderSpec.Versioning.Type))
+	}
+	if folderSpec.RescanIntervalS != nil && *folderSpec.RescanIntervalS < 0 {
+		errs = append(errs, fmt.Errorf("folder rescanIntervalS cannot be negative, got %d", *folderSpec.RescanIntervalS))
+	}
+	return errorsutil.NewAggregate(errs)
+}
+
+// hasManagedFolder Returns 'true' when the folder holding the data is part of Syncthing's config.
+func hasManagedFolder(syncthing *api.Syncthing) bool {
+	for _, folder := range syncthing.Configuration.Folders {
+		if folder.ID == managedFolderID {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureManagedFolder Adds the folder holding the data back to Syncthing's config when it's missing,
+// sharing it with all of the configured devices, and returns 'true' if it had to be added.
+// The folder is created from Syncthing's folder defaults, so the options from the spec still need
+// to be applied to it.
+func ensureManagedFolder(syncthing *api.Syncthing) bool {
+	if hasManagedFolder(syncthing) {
+		return false
+	}
+	folder := syncthing.Configuration.Defaults.Folder.Copy()
+	folder.ID = managedFolderID
+	folder.Label = defaultFolderLabel
+	folder.Path = dataDirMountPath
+	folder.Type = config.FolderTypeSendReceive
+	syncthing.Configuration.Folders = append(syncthing.Configuration.Folders, folder)
+	syncthing.ShareFoldersWithDevices(syncthing.Configuration.Devices)
+	return true
+}
+
+// updateFolderTuning Applies the options tuning how Syncthing reads & writes the folder's files,
+// and returns 'true' if any of them were changed.
+func updateFolderTuning(folderSpec *v1alpha1.SyncthingFolderSpec, folder *config.FolderConfiguration) bool {
+	maxConcurrentWrites := int(folderSpec.MaxConcurrentWrites)
+	if maxConcurrentWrites == 0 {
+		maxConcurrentWrites = defaultFolderMaxConcurrentWrites
+	}
+	// an empty or unknown order is parsed as the standard one
+	var blockPullOrder config.BlockPullOrder
+	_ = blockPullOrder.UnmarshalText([]byte(folderSpec.BlockPullOrder))
+
+	hasChanged := false
+	if folder.MaxConcurrentWrites != maxConcurrentWrites {
+		folder.MaxConcurrentWrites = maxConcurrentWrites
+		hasChanged = true
+	}
+	if folder.RawModTimeWindowS != int(folderSpec.ModTimeWindowS) {
+		folder.RawModTimeWindowS = int(folderSpec.ModTimeWindowS)
+		hasChanged = true
+	}
+	if folder.PullerMaxPendingKiB != int(folderSpec.PullerMaxPendingKiB) {
+		folder.PullerMaxPendingKiB = int(folderSpec.PullerMaxPendingKiB)
+		hasChanged = true
+	}
+	if folder.BlockPullOrder != blockPullOrder {
+		folder.BlockPullOrder = blockPullOrder
+		hasChanged = true
+	}
+	if folderSpec.RescanIntervalS != nil && folder.RescanIntervalS != int(*folderSpec.RescanIntervalS) {
+		folder.RescanIntervalS = int(*folderSpec.RescanIntervalS)
+		hasChanged = true
+	}
+	if folderSpec.FSWatcherEnabled != nil && folder.FSWatcherEnabled != *folderSpec.FSWatcherEnabled {
+		folder.FSWatcherEnabled = *folderSpec.FSWatcherEnabled
+		hasChanged = true
+	}
+	return hasChanged
+}
+
+// updateFolderXattrs Applies the extended attribute options from the given folder spec to the folder,
+// and returns 'true' if any of them were changed.
+func updateFolderXattrs(folderSpec *v1alpha1.SyncthingFolderSpec, folder *config.FolderConfiguration) bool {
+	hasChanged := false
+	if folder.SendXattrs != folderSpec.SendXattrs {
+		folder.SendXattrs = folderSpec.SendXattrs
+		hasChanged = true
+	}
+	if folder.SyncXattrs != folderSpec.SyncXattrs {
+		folder.SyncXattrs = folderSpec.SyncXattrs
+		hasChanged = true
+	}
+
+	entries := []config.XattrFilterEntry{}
+	for _, entry := range folderSpec.XattrFilter {
+		entries = append(entries, config.XattrFilterEntry{Match: entry.Match, Permit: entry.Permit})
+	}
+	entriesChanged := len(folder.XattrFilter.Entries) != len(entries)
+	for i := 0; !entriesChanged && i < len(entries); i++ {
+		entriesChanged = folder.XattrFilter.Entries[i] != entries[i]
+	}
+	if entriesChanged {
+		folder.XattrFilter.Entries = entries
+		hasChanged = true
+	}
+	return hasChanged
+}
+
+// updateFolderOwnership Applies the ownership options of the given folder spec to the folder, and
+// returns 'true' if any of them were changed.
+func updateFolderOwnership(folderSpec *v1alpha1.SyncthingFolderSpec, folder *config.FolderConfiguration) bool {
+	hasChanged := false
+	if folder.CopyOwnershipFromParent != folderSpec.CopyOwnershipFromParent {
+		folder.CopyOwnershipFromParent = folderSpec.CopyOwnershipFromParent
+		hasChanged = true
+	}
+	if folder.SendOwnership != folderSpec.SendOwnership {
+		folder.SendOwnership = folderSpec.SendOwnership
+		hasChanged = true
+	}
+	if folder.SyncOwnership != folderSpec.SyncOwnership {
+		folder.SyncOwnership = folderSpec.SyncOwnership
+		hasChanged = true
+	}
+	return hasChanged
+}
+
+// changesOwnership Returns 'true' when the given folder spec has Syncthing change the ownership of
+// files, which requires the CHOWN capability.
+func changesOwnership(folderSpec *v1alpha1.SyncthingFolderSpec) bool {
+	return folderSpec != nil && (folderSpec.CopyOwnershipFromParent || folderSpec.SyncOwnership)
+}
+
+// updateFolderVersioning Applies the given versioning to the folder, disabling versioning when none is
+// given, and returns 'true' if it was changed. The options Syncthing fills in itself, like the cleanup
+// interval, are left untouched.
+func updateFolderVersioning(versioningSpec *v1alpha1.SyncthingVersioningSpec, folder *config.FolderConfiguration) bool {
+	versioningType := ""
+	params := map[string]string{}
+	if versioningSpec != nil {
+		versioningType = versioningSpec.Type
+		for key, value := range versioningSpec.Params {
+			params[key] = value
+		}
+	}
+
+	paramsChanged := len(folder.Versioning.Params) != len(params)
+	for key, value := range params {
+		if current, found := folder.Versioning.Params[key]; !found || current != value {
+			paramsChanged = true
+		}
+	}
+	if folder.Versioning.Type == versioningType && !paramsChanged {
+		return false
+	}
+	folder.Versioning.Type = versioningType
+	folder.Versioning.Params = params
+	return true
+}
+
+// updateSyncthingOptions Applies the options from the given options spec to Syncthing's global options,
+// and returns 'true' if any of them were changed. Options which aren't set are left untouched.
+func updateSyncthingOptions(optionsSpec *v1alpha1.SyncthingOptionsSpec, syncthing *api.Syncthing) (bool, error) {
+	if err := validateOptionsSpec(optionsSpec); err != nil {
+		return false, err
+	}
+
+	options := &syncthing.Configuration.Options
+	if optionsSpec == nil {
+		// relays pinned before are released along with the other options
+		return restoreDefaultRelays(options), nil
+	}
+	hasChanged := updateGlobalRateLimits(optionsSpec, options)
+	if optionsSpec.ProgressUpdateIntervalS != nil {
+		interval := int(*optionsSpec.ProgressUpdateIntervalS)
+		if options.ProgressUpdateIntervalS != interval {
+			options.ProgressUpdateIntervalS = interval
+			hasChanged = true
+		}
+	}
+	if optionsSpec.AnnounceLANAddresses != nil && options.AnnounceLANAddresses != *optionsSpec.AnnounceLANAddresses {
+		options.AnnounceLANAddresses = *optionsSpec.AnnounceLANAddresses
+		hasChanged = true
+	}
+	if optionsSpec.LocalAnnounceEnabled != nil && options.LocalAnnEnabled != *optionsSpec.LocalAnnounceEnabled {
+		options.LocalAnnEnabled = *optionsSpec.LocalAnnounceEnabled
+		hasChanged = true
+	}
+	if optionsSpec.TempIndexMinBlocks != nil {
+		minBlocks := int(*optionsSpec.TempIndexMinBlocks)
+		if options.TempIndexMinBlocks != minBlocks {
+			options.TempIndexMinBlocks = minBlocks
+			hasChanged = true
+		}
+	}
+	if len(optionsSpec.RelayServers) > 0 {
+		if updateRelayServers(optionsSpec.RelayServers, options) {
+			hasChanged = true
+		}
+	} else if restoreDefaultRelays(options) {
+		hasChanged = true
+	}
+	if len(optionsSpec.DiscoveryServers) > 0 && updateDiscoveryServers(optionsSpec.DiscoveryServers, options) {
+		hasChanged = true
+	}
+	if optionsSpec.KeepTemporariesH != nil {
+		keepTemporaries := int(*optionsSpec.KeepTemporariesH)
+		if options.KeepTemporariesH != keepTemporaries {
+			options.KeepTemporariesH = keepTemporaries
+			hasChanged = true
+		}
+	}
+	return hasChanged, nil
+}
+
+// validateOptionsSpec Returns the errors found with any of the given options, which can't be applied by Syncthing.
+func validateOptionsSpec(optionsSpec *v1alpha1.SyncthingOptionsSpec) error {
+	if optionsSpec == nil {
+		return nil
+	}
+	errs := []error{}
+	if optionsSpec.ProgressUpdateIntervalS != nil && *optionsSpec.ProgressUpdateIntervalS <= 0 {
+		errs = append(errs, fmt.Errorf("progressUpdateIntervalS must be positive, got %d",
+			*optionsSpec.ProgressUpdateIntervalS))
+	}
+	if optionsSpec.TempIndexMinBlocks != nil && *optionsSpec.TempIndexMinBlocks < 0 {
+		errs = append(errs, fmt.Errorf("tempIndexMinBlocks cannot be negative, got %d",
+			*optionsSpec.TempIndexMinBlocks))
+	}
+	for _, relay := range optionsSpec.RelayServers {
+		if err := validateRelayServer(relay); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, server := range optionsSpec.DiscoveryServers {
+		if err := validateDiscoveryServer(server); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if optionsSpec.KeepTemporariesH != nil && *optionsSpec.KeepTemporariesH < 0 {
+		errs = append(errs, fmt.Errorf("keepTemporariesH cannot be negative, got %d",
+			*optionsSpec.KeepTemporariesH))
+	}
+	if optionsSpec.MaxSendKbps != nil && *optionsSpec.MaxSendKbps < 0 {
+		errs = append(errs, fmt.Errorf("maxSendKbps cannot be negative, got %d", *optionsSpec.MaxSendKbps))
+	}
+	if optionsSpec.MaxRecvKbps != nil && *optionsSpec.MaxRecvKbps < 0 {
+		errs = append(errs, fmt.Errorf("maxRecvKbps cannot be negative, got %d", *optionsSpec.MaxRecvKbps))
+	}
+	return errorsutil.NewAggregate(errs)
+}
+
+// updateGlobalRateLimits Applies the limits on the rates at which Syncthing sends and receives data
+// across all of its peers, leaving those which aren't set alone, and returns 'true' if they were changed.
+func updateGlobalRateLimits(optionsSpec *v1alpha1.SyncthingOptionsSpec, options *config.OptionsConfiguration) bool {
+	hasChanged := false
+	if optionsSpec.MaxSendKbps != nil && options.MaxSendKbps != int(*optionsSpec.MaxSendKbps) {
+		options.MaxSendKbps = int(*optionsSpec.MaxSendKbps)
+		hasChanged = true
+	}
+	if optionsSpec.MaxRecvKbps != nil && options.MaxRecvKbps != int(*optionsSpec.MaxRecvKbps) {
+		options.MaxRecvKbps = int(*optionsSpec.MaxRecvKbps)
+		hasChanged = true
+	}
+	return hasChanged
+}
+
+// updateRelayServers Enables relaying through the given relays only, replacing the public relay pool and
+// any other relay in Syncthing's listen addresses, and returns 'true' if the options were changed.
+func updateRelayServers(relays []string, options *config.OptionsConfiguration) bool {
+	listenAddresses := []string{}
+	for _, address := range options.RawListenAddresses {
+		if address == "default" {
+			// keep the default listeners, other than the public relay pool
+			for _, defaultAddress := range config.DefaultListenAddresses {
+				if !isRelayAddress(defaultAddress) {
+					listenAddresses = append(listenAddresses, defaultAddress)
+				}
+			}
+		} else if !isRelayAddress(address) {
+			listenAddresses = append(listenAddresses, address)
+		}
+	}
+	listenAddresses = append(listenAddresses, relays...)
+
+	if options.RelaysEnabled && reflect.DeepEqual(options.RawListenAddresses, listenAddresses) {
+		return false
+	}
+	options.RelaysEnabled = true
+	options.RawListenAddresses = listenAddresses
+	return true
+}
+
+// restoreDefaultRelays Reverts the listen addresses pinned to a list of relays by updateRelayServers back
+// to Syncthing's default listeners and relay pool, and returns 'true' if the options were changed.
+// Listen addresses which weren't pinned, such as those set through the GUI, are left alone.
+func restoreDefaultRelays(options *config.OptionsConfiguration) bool {
+	defaultAddresses := map[string]bool{}
+	for _, address := range config.DefaultListenAddresses {
+		if !isRelayAddress(address) {
+			defaultAddresses[address] = true
+		}
+	}
+
+	listenAddresses := []string{"default"}
+	pinnedRelays, pinnedDefaults := 0, 0
+	for _, address := range options.RawListenAddresses {
+		switch {
+		case address == "default" || strings.HasPrefix(address, "dynamic+"):
+			// the relays aren't pinned while a pool is listened on
+			return false
+		case defaultAddresses[address]:
+			pinnedDefaults++
+		case isRelayAddress(address):
+			pinnedRelays++
+		default:
+			listenAddresses = append(listenAddresses, address)
+		}
+	}
+	if pinnedRelays == 0 || pinnedDefaults != len(defaultAddresses) {
+		return false
+	}
+	options.RelaysEnabled = true
+	options.RawListenAddresses = listenAddresses
+	return true
+}
+
+// isRelayAddress Determines whether the given listen address makes Syncthing connect through relays,
+// either a single one or a pool of relays, such as the public one.
+func isRelayAddress(address string) bool {
+	return strings.HasPrefix(address, "relay://") || strings.HasPrefix(address, "dynamic+")
+}
+
+// validateRelayServer Returns an error if the given address isn't a relay Syncthing can connect
+// through, e.g. relay://relay.example.com:22067/?id=<relay ID>
+func validateRelayServer(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("could not parse relay %q: %w", address, err)
+	}
+	if u.Scheme != "relay" {
+		return fmt.Errorf("relay %q must use the relay:// scheme", address)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return fmt.Errorf("relay %q must specify a host and port", address)
+	}
+	if id := u.Query().Get("id"); id != "" {
+		if _, err := protocol.DeviceIDFromString(id); err != nil {
+			return fmt.Errorf("relay %q has an invalid ID: %w", address, err)
+		}
+	}
+	return nil
+}
+
+// updateDiscoveryServers Enables global discovery through the given servers only, in place of the public
+// discovery servers, and returns 'true' if the options were changed.
+func updateDiscoveryServers(servers []string, options *config.OptionsConfiguration) bool {
+	if options.GlobalAnnEnabled && reflect.DeepEqual(options.RawGlobalAnnServers, servers) {
+		return false
+	}
+	options.GlobalAnnEnabled = true
+	options.RawGlobalAnnServers = append([]string{}, servers...)
+	return true
+}
+
+// validateDiscoveryServer Returns an error if the given address isn't a global discovery server Syncthing
+// can use, e.g. https://discovery.example.com:8443/?id=<server ID>
+func validateDiscoveryServer(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("could not parse discovery server %q: %w", address, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("discovery server %q must use the https:// scheme", address)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("discovery server %q must specify a host", address)
+	}
+	if id := u.Query().Get("id"); id != "" {
+		if _, err := protocol.DeviceIDFromString(id); err != nil {
+			return fmt.Errorf("discovery server %q has an invalid ID: %w", address, err)
+		}
+	}
+	return nil
+}
+
+// getFolderPeers Returns the IDs of the devices the given folder is shared with, other than the node itself.
+func getFolderPeers(folder config.FolderConfiguration, myID string) []string {
+	peers := []string{}
+	for _, device := range folder.Devices {
+		if device.DeviceID.GoString() != myID {
+			peers = append(peers, device.DeviceID.GoString())
+		}
+	}
+	return peers
+}
+
+// getFolderStatuses Returns the status of each of the folders shared by Syncthing, estimating the ETA of
+// the syncing folders from the given transfer rate. Folders whose status or contents weren't fetched keep
+// those from their previous status.
+func getFolderStatuses(syncthing *api.Syncthing, previous []v1alpha1.SyncthingFolderStatus,
+	transferRate float64) []v1alpha1.SyncthingFolderStatus {
+	folderStatuses := []v1alpha1.SyncthingFolderStatus{}
+	for _, folder := range syncthing.Configuration.Folders {
+		folderStatus := v1alpha1.SyncthingFolderStatus{State: v1alpha1.SyncthingFolderStateUnknown}
+		if previousStatus := findFolderStatus(previous, folder.ID); previousStatus != nil {
+			folderStatus = *previousStatus.DeepCopy()
+		}
+		folderStatus.ID = folder.ID
+		folderStatus.SharedWith = getFolderPeers(folder, syncthing.MyID())
+
+		if entries, fetched := syncthing.FolderEntries[folder.ID]; fetched {
+			conflicts, conflictingFiles := findConflicts("", entries)
+			folderStatus.Conflicts = int32(conflicts)
+			folderStatus.ConflictingFiles = conflictingFiles
+		}
+		// folders which are still being scanned report the partial totals they know about so far
+		if status, fetched := syncthing.FolderStatuses[folder.ID]; fetched {
+			folderStatus.State = normalizeFolderState(status.State)
+			folderStatus.NeedBytes = status.NeedBytes
+			folderStatus.GlobalFiles = int64(status.GlobalFiles)
+			folderStatus.GlobalBytes = status.GlobalBytes
+			folderStatus.ETA = estimateFolderETA(folderStatus.State, status.NeedBytes, transferRate)
+		}
+		folderStatuses = append(folderStatuses, folderStatus)
+	}
+	return folderStatuses
+}
+
+// findFolderStatus Returns the status of the folder with the given ID, or nil if there's none.
+func findFolderStatus(statuses []v1alpha1.SyncthingFolderStatus, folderID string) *v1alpha1.SyncthingFolderStatus {
+	for i := range statuses {
+		if statuses[i].ID == folderID {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// countFoldersInError Returns the number of the given folders which are either in the error state,
+// or have items which failed to sync.
+func countFoldersInError(syncthing *api.Syncthing, folders []v1alpha1.SyncthingFolderStatus) int32 {
+	var inError int32
+	for _, folder := range folders {
+		status := syncthing.FolderStatuses[folder.ID]
+		if status.Errors > 0 || folder.State == v1alpha1.SyncthingFolderStateError {
+			inError++
+		}
+	}
+	return inError
+}
+
+// redactSyncthingConfig Returns a copy of the given Syncthing config that is safe to log, with the GUI
+// credentials and the encryption passwords of the folders' devices redacted.
+func redactSyncthingConfig(syncthingConfig *config.Configuration) config.Configuration {
+	redacted := *syncthingConfig
+	redacted.GUI.APIKey = redactedValue
+	redacted.GUI.Password = redactedValue
+	redacted.Folders = make([]config.FolderConfiguration, len(syncthingConfig.Folders))
+	for i, folder := range syncthingConfig.Folders {
+		folder.Devices = make([]config.FolderDeviceConfiguration, len(folder.Devices))
+		for j, device := range syncthingConfig.Folders[i].Devices {
+			if device.EncryptionPassword != "" {
+				device.EncryptionPassword = redactedValue
+			}
+			folder.Devices[j] = device
+		}
+		redacted.Folders[i] = folder
+	}
+	return redacted
+}
+
+// renderSyncthingConfig Renders the devices and folders of the given Syncthing config as JSON for review.
+// The GUI credentials are redacted.
+func renderSyncthingConfig(syncthingConfig *config.Configuration) (string, error) {
+	redacted := redactSyncthingConfig(syncthingConfig)
+	rendered, err := json.MarshalIndent(struct {
+		Devices []config.DeviceConfiguration `json:"devices"`
+		Folders []config.FolderConfiguration `json:"folders"`
+		GUI     config.GUIConfiguration      `json:"gui"`
+	}{
+		Devices: redacted.Devices,
+		Folders: redacted.Folders,
+		GUI:     redacted.GUI,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(rendered), nil
+}
+
+// estimateIndexSize Returns the approximate size in bytes of Syncthing's index database for the given folders,
+// from the number of files and blocks it keeps track of.
+func estimateIndexSize(folders []v1alpha1.SyncthingFolderStatus) int64 {
+	var size int64
+	for _, folder := range folders {
+		blocks := (folder.GlobalBytes + indexBlockSize - 1) / indexBlockSize
+		size += folder.GlobalFiles*indexBytesPerFile + blocks*indexBytesPerBlock
+	}
+	return size
+}
+
+// scaleConfigCapacity Returns the size of the config volume needed for the given number of folders.
+// The base capacity covers the first folder, and each additional folder adds configCapacityPerFolder.
+func scaleConfigCapacity(base resource.Quantity, folders int) resource.Quantity {
+	capacity := base.DeepCopy()
+	perFolder := resource.MustParse(configCapacityPerFolder)
+	for i := 1; i < folders; i++ {
+		capacity.Add(perFolder)
+	}
+	return capacity
+}
+
+// getNodeAddress Returns the address through which the given node can be reached,
+// preferring its external IP over its internal one.
+func getNodeAddress(node *corev1.Node) string {
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType && address.Address != "" {
+				return address.Address
+			}
+		}
+	}
+	return ""
+}
+
+// getTransferRate Returns the rate, in bytes per second, at which data is currently being received from
+// the connected peers, from the bytes received since the previous status of the peers, which was observed
+// the given time ago. Peers which weren't connected throughout, or whose connection was reset, aren't
+// counted, as the bytes they received since then aren't known.
+func getTransferRate(previousPeers []v1alpha1.SyncthingPeerStatus, peers []v1alpha1.SyncthingPeerStatus,
+	elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	previousInBytes := map[string]int64{}
+	for _, peer := range previousPeers {
+		if peer.Connected {
+			previousInBytes[peer.ID] = peer.InBytesTotal
+		}
+	}
+	var received int64
+	for _, peer := range peers {
+		previous, found := previousInBytes[peer.ID]
+		if peer.Connected && found && peer.InBytesTotal >= previous {
+			received += peer.InBytesTotal - previous
+		}
+	}
+	return float64(received) / elapsed.Seconds()
+}
+
+// getLastSeen Returns when the device with the given statistics was last seen,
+// or nil if it has never been seen.
+func getLastSeen(stats api.DeviceStats) *metav1.Time {
+	lastSeen, err := time.Parse(time.RFC3339Nano, stats.LastSeen)
+	// Syncthing reports devices that were never seen as last seen at the epoch
+	if err != nil || lastSeen.Unix() <= 0 {
+		return nil
+	}
+	return &metav1.Time{Time: lastSeen}
+}
+
+// estimateFolderETA Estimates the time remaining until a folder is in sync given the bytes it
+// still needs and the current transfer rate. Folders that aren't syncing have no ETA.
+func estimateFolderETA(state string, needBytes int64, transferRate float64) string {
+	if state != v1alpha1.SyncthingFolderStateSyncing || needBytes <= 0 {
+		return ""
+	}
+	if transferRate <= 0 {
+		return v1alpha1.SyncthingFolderETAUnknown
+	}
+	eta := time.Duration(float64(needBytes) / transferRate * float64(time.Second))
+	return eta.Round(time.Second).String()
+}
+
+// normalizeFolderState Maps the given Syncthing folder state onto the stable set of states
+// reported in the status. States that aren't recognized are reported as Unknown.
+func normalizeFolderState(state string) string {
+	switch state {
+	case "idle":
+		return v1alpha1.SyncthingFolderStateIdle
+	case "scanning", "scan-waiting":
+		return v1alpha1.SyncthingFolderStateScanning
+	case "syncing", "sync-waiting", "sync-preparing", "cleaning", "clean-waiting":
+		return v1alpha1.SyncthingFolderStateSyncing
+	case "error":
+		return v1alpha1.SyncthingFolderStateError
+	default:
+		return v1alpha1.SyncthingFolderStateUnknown
+	}
+}
+
+// findConflicts Walks the given entries and returns the number of conflicting files found,
+// along with the paths of up to maxConflictsReported of them.
+func findConflicts(parent string, entries []api.FileEntry) (int, []string) {
+	conflicts := 0
+	conflictingFiles := []string{}
+	for _, entry := range entries {
+		entryPath := path.Join(parent, entry.Name)
+		// Syncthing names conflicting copies as <name>.sync-conflict-<date>-<time>-<device>.<ext>
+		if strings.Contains(entry.Name, ".sync-conflict-") {
+			conflicts++
+			if len(conflictingFiles) < maxConflictsReported {
+				conflictingFiles = append(conflictingFiles, entryPath)
+			}
+		}
+		childConflicts, childFiles := findConflicts(entryPath, entry.Children)
+		conflicts += childConflicts
+		for _, file := range childFiles {
+			if len(conflictingFiles) < maxConflictsReported {
+				conflictingFiles = append(conflictingFiles, file)
+			}
+		}
+	}
+	return conflicts, conflictingFiles
+}
+
 // syncthingNeedsReconfigure Determines whether the given nodeList differs from Syncthing's internal devices,
 // and returns 'true' if the Syncthing API must be reconfigured, 'false' otherwise.
 func syncthingNeedsReconfigure(
 	nodeList []v1alpha1.SyncthingPeer,
 	syncthing *api.Syncthing,
 ) bool {
-	// check if the syncthing nodelist diverges from the current syncthing devices
-	var newDevices map[string]v1alpha1.SyncthingPeer = map[string]v1alpha1.SyncthingPeer{
-		// initialize the map with the self node
-		syncthing.MyID(): {
-			ID:      syncthing.MyID(),
-			Address: "",
-		},
-	}
-
-	// add all of the other devices in the provided nodeList
+	// create a map of the devices in the provided nodeList
+	newDevices := map[string]v1alpha1.SyncthingPeer{}
 	for _, device := range nodeList {
 		// avoid self
 		if device.ID == syncthing.MyID() {
@@ -84,34 +876,23 @@ func syncthingNeedsReconfigure(
 	}
 
 	// create a map for current devices
-	var currentDevs map[string]v1alpha1.SyncthingPeer = map[string]v1alpha1.SyncthingPeer{
-		// initialize the map with the self node
-		syncthing.MyID(): {
-			ID:      syncthing.MyID(),
-			Address: "",
-		},
-	}
-	// add the rest of devices to the map
+	currentDevs := map[string]config.DeviceConfiguration{}
 	for _, device := range syncthing.Configuration.Devices {
 		// ignore self and introduced devices
 		if device.DeviceID.GoString() == syncthing.MyID() || device.IntroducedBy.GoString() != "" {
 			continue
 		}
-
-		currentDevs[device.DeviceID.GoString()] = v1alpha1.SyncthingPeer{
-			ID:      device.DeviceID.GoString(),
-			Address: device.Addresses[0],
-		}
+		currentDevs[device.DeviceID.GoString()] = device
 	}
 
-	// check if the syncthing nodelist diverges from the current syncthing devices
-	for _, device := range newDevices {
-		if _, ok := currentDevs[device.ID]; !ok {
-			return true
-		}
+	// check if the syncthing nodelist diverges from the current syncthing devices,
+	// comparing each of the per-device options as well
+	if len(newDevices) != len(currentDevs) {
+		return true
 	}
-	for _, device := range currentDevs {
-		if _, ok := newDevices[device.ID]; !ok {
+	for id, peer := range newDevices {
+		device, ok := currentDevs[id]
+		if !ok || deviceDiffersFromPeer(device, peer) {
 			return true
 		}
 	}
@@ -168,3 +949,106 @@ func asTCPAddress(address string) string {
 
 	return "tcp://" + address
 }
+
+// validateSyncthingAddress Returns an error if the given address is not one that Syncthing
+// can use to connect to a device, e.g. tcp://example.com:22000
+func validateSyncthingAddress(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("could not parse address %q: %w", address, err)
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "quic", "quic4", "quic6", "relay":
+	default:
+		return fmt.Errorf("address %q has an unsupported scheme %q", address, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("address %q is missing a host", address)
+	}
+	return nil
+}
+
+// configPersisted Returns 'true' when the devices, folders and GUI user of the written config are
+// found in the config read back from Syncthing. Fields that Syncthing changes when saving, like the
+// GUI password which it hashes, aren't compared.
+func configPersisted(written, readBack *config.Configuration) bool {
+	if written.GUI.User != readBack.GUI.User ||
+		len(written.Devices) != len(readBack.Devices) ||
+		len(written.Folders) != len(readBack.Folders) {
+		return false
+	}
+	devices := map[protocol.DeviceID]string{}
+	for _, device := range readBack.Devices {
+		devices[device.DeviceID] = strings.Join(device.Addresses, ",")
+	}
+	for _, device := range written.Devices {
+		addresses, found := devices[device.DeviceID]
+		if !found || addresses != strings.Join(device.Addresses, ",") {
+			return false
+		}
+	}
+	folders := map[string]bool{}
+	for _, folder := range readBack.Folders {
+		folders[folder.ID] = true
+	}
+	for _, folder := range written.Folders {
+		if !folders[folder.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSyncthingAddress Splits the given address into its scheme, host and port.
+// The port is 0 when the address doesn't specify one.
+func splitSyncthingAddress(address string) (string, string, int32, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("could not parse address %q: %w", address, err)
+	}
+	var port int64
+	if u.Port() != "" {
+		port, err = strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("address %q has an invalid port: %w", address, err)
+		}
+	}
+	return u.Scheme, u.Hostname(), int32(port), nil
+}
+
+// allPeersConnected Returns 'true' when every peer in the given list, other than the node itself,
+// is reported as connected in the given peer statuses.
+func allPeersConnected(peerList []v1alpha1.SyncthingPeer, peerStatuses []v1alpha1.SyncthingPeerStatus,
+	myID string) bool {
+	connected := map[string]bool{}
+	for _, peer := range peerStatuses {
+		connected[peer.ID] = peer.Connected
+	}
+	for _, peer := range peerList {
+		if peer.ID != myID && !connected[peer.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// isNamespacedSysctl Determines whether the given sysctl is namespaced, following the same rules as
+// Kubernetes: the IPC sysctls and those of the network stack can be set per pod.
+func isNamespacedSysctl(name string) bool {
+	for _, prefix := range []string{"kernel.shm", "kernel.msg", "fs.mqueue.", "net."} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return name == "kernel.sem"
+}
+
+// imagePullPolicyFor Returns the pull policy suited to the given image reference. An image pinned
+// by digest can't change, so it only needs to be pulled when not present, whereas a tag may be moved
+// to another image at any time, so it's always pulled.
+func imagePullPolicyFor(image string) corev1.PullPolicy {
+	if strings.Contains(image, "@") {
+		return corev1.PullIfNotPresent
+	}
+	return corev1.PullAlways
+}
@@ -17,29 +17,50 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package syncthing
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	cMover "github.com/backube/volsync/controllers/mover"
 	"github.com/backube/volsync/controllers/mover/syncthing/api"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/protocol"
+	"go.uber.org/zap/zapcore"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/events"
+	testingclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
@@ -86,6 +107,141 @@ var _ = Describe("Syncthing ignores other movers", func() {
 	})
 })
 
+var _ = Describe("Syncthing validates the volume names & secret keys", func() {
+	var logger = zap.New(zap.UseDevMode(true), zap.WriteTo(GinkgoWriter))
+
+	It("errors when the volume names collide", func() {
+		rs := &volsyncv1alpha1.ReplicationSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rs-test",
+				Namespace: "default",
+			},
+			Spec: volsyncv1alpha1.ReplicationSourceSpec{
+				Syncthing: &volsyncv1alpha1.ReplicationSourceSyncthingSpec{
+					ConfigVolumeName: pointer.String("my-volume"),
+					DataVolumeName:   pointer.String("my-volume"),
+				},
+			},
+			Status: &volsyncv1alpha1.ReplicationSourceStatus{},
+		}
+
+		mover, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{}, rs,
+			true /* privileged */)
+		Expect(mover).To(BeNil())
+		Expect(err).To(HaveOccurred())
+
+		// the certificates volume is reserved as well
+		rs.Spec.Syncthing.DataVolumeName = pointer.String(certVolumeName)
+		mover, err = commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{}, rs,
+			true /* privileged */)
+		Expect(mover).To(BeNil())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("errors when the apikey would overwrite another value in the API secret", func() {
+		rs := &volsyncv1alpha1.ReplicationSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rs-test",
+				Namespace: "default",
+			},
+			Spec: volsyncv1alpha1.ReplicationSourceSpec{
+				Syncthing: &volsyncv1alpha1.ReplicationSourceSyncthingSpec{
+					APIKeySecretKey: pointer.String(passwordDataKey),
+				},
+			},
+			Status: &volsyncv1alpha1.ReplicationSourceStatus{},
+		}
+
+		mover, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{}, rs,
+			true /* privileged */)
+		Expect(mover).To(BeNil())
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Syncthing validates a spec ahead of time", func() {
+	const peerID = "AIR6LPZ-7K4PTTV-UXQSMUU-CPQ5YWH-OEDFIIQ-JUG777G-2YQXXR5-YD6AWQR"
+
+	It("computes the config described by a valid spec", func() {
+		syncthingConfig, err := ValidateSpec(&volsyncv1alpha1.ReplicationSourceSyncthingSpec{
+			Peers: []volsyncv1alpha1.SyncthingPeer{{ID: peerID, Address: "tcp://127.0.0.1:22000"}},
+			Folder: &volsyncv1alpha1.SyncthingFolderSpec{
+				Label:        "my data",
+				IgnoreDelete: true,
+			},
+			Options: &volsyncv1alpha1.SyncthingOptionsSpec{TempIndexMinBlocks: pointer.Int32(0)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(syncthingConfig.Devices).To(HaveLen(1))
+		Expect(syncthingConfig.Devices[0].DeviceID.GoString()).To(Equal(peerID))
+		Expect(syncthingConfig.Devices[0].Addresses).To(Equal([]string{"tcp://127.0.0.1:22000"}))
+		Expect(syncthingConfig.Folders).To(HaveLen(1))
+		Expect(syncthingConfig.Folders[0].ID).To(Equal(managedFolderID))
+		Expect(syncthingConfig.Folders[0].Path).To(Equal(dataDirMountPath))
+		Expect(syncthingConfig.Folders[0].Label).To(Equal("my data"))
+		Expect(syncthingConfig.Folders[0].IgnoreDelete).To(BeTrue())
+		Expect(syncthingConfig.Folders[0].Devices).To(HaveLen(1))
+		Expect(syncthingConfig.Options.TempIndexMinBlocks).To(BeZero())
+	})
+
+	It("reports all of the problems with an invalid spec together", func() {
+		serviceType := corev1.ServiceType("Bogus")
+		syncthingConfig, err := ValidateSpec(&volsyncv1alpha1.ReplicationSourceSyncthingSpec{
+			Peers: []volsyncv1alpha1.SyncthingPeer{
+				{ID: "not-a-device-id", Address: "tcp://127.0.0.1:22000"},
+				{ID: peerID, Address: "http://127.0.0.1:22000"},
+				{ID: peerID, Address: "tcp://127.0.0.2:22000"},
+			},
+			MaxPeers:          pointer.Int32(2),
+			ServiceType:       &serviceType,
+			AdvertisedAddress: pointer.String("example.com"),
+			Sysctls:           []corev1.Sysctl{{Name: "vm.swappiness", Value: "10"}},
+			ConfigVolumeName:  pointer.String(certVolumeName),
+			Folder:            &volsyncv1alpha1.SyncthingFolderSpec{FilesystemType: "encrypted"},
+			Options: &volsyncv1alpha1.SyncthingOptionsSpec{
+				ProgressUpdateIntervalS: pointer.Int32(0),
+				RelayServers:            []string{"tcp://relay.example.com:22067"},
+				DiscoveryServers:        []string{"http://discovery.example.com"},
+			},
+			SyncInterval: &metav1.Duration{},
+			ExposeAPI:    true,
+			PodLocalAPI:  true,
+			MoverImage:   pointer.String("registry.example.com/Vol Sync"),
+			IndexSnapshots: &volsyncv1alpha1.SyncthingIndexSnapshotsSpec{
+				Interval: &metav1.Duration{Duration: time.Minute},
+			},
+			StatsSnapshots: &volsyncv1alpha1.SyncthingStatsSnapshotsSpec{
+				Interval: &metav1.Duration{},
+			},
+		})
+		Expect(syncthingConfig).To(BeNil())
+		Expect(err).To(HaveOccurred())
+
+		for _, problem := range []string{
+			"peers[0]",
+			"peers[1]",
+			"peers[2]: duplicate peer",
+			"exceeding the maximum of 2",
+			`unsupported serviceType "Bogus"`,
+			"advertisedAddress",
+			"vm.swappiness",
+			certVolumeName,
+			`filesystemType "encrypted"`,
+			"progressUpdateIntervalS",
+			"relay.example.com",
+			"discovery.example.com",
+			"syncInterval must be positive",
+			"exposeAPI and podLocalAPI",
+			"moverImage",
+			"indexSnapshots.interval must be at least 1h0m0s",
+			"statsSnapshots.interval must be at least 1m0s",
+		} {
+			Expect(err.Error()).To(ContainSubstring(problem))
+		}
+	})
+})
+
 var _ = Describe("Syncthing doesn't implement RD", func() {
 	var ctx = context.TODO()
 	var ns *corev1.Namespace
@@ -286,12 +442,29 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(deployment).NotTo(BeNil())
 
 					// make sure the service is created
-					svc, err := mover.ensureDataService(ctx, deployment)
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(svc).NotTo(BeNil())
 					Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
 				})
 
+				It("selects the pods using the labels from the pod template", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(deployment.Spec.Template.Labels).NotTo(BeEmpty())
+
+					// customized pod labels must carry over to the services
+					deployment.Spec.Template.Labels["volsync-test/custom"] = "kramerica"
+
+					dataSVC, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(dataSVC.Spec.Selector).To(Equal(deployment.Spec.Template.Labels))
+
+					apiSVC, err := mover.ensureAPIService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(apiSVC.Spec.Selector).To(Equal(deployment.Spec.Template.Labels))
+				})
+
 				It("Can get DataServiceAddress", func() {
 					// test data
 					const staticIP string = "1.2.3.4"
@@ -334,7 +507,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(deployment).NotTo(BeNil())
 
 					// make sure the service is created
-					svc, err := mover.ensureDataService(ctx, deployment)
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(svc).NotTo(BeNil())
 					Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
@@ -377,11 +550,246 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(e).NotTo(HaveOccurred())
 					Expect(address).To(Equal("tcp://" + staticIP + ":" + strconv.Itoa(dataPort)))
 
-					// ensure address works when hostname is provided
+					// the IP is preferred when a hostname is provided as well
 					svc.Status.LoadBalancer.Ingress[0].Hostname = staticHostName
 					address, e = mover.GetDataServiceAddress(svc)
 					Expect(e).NotTo(HaveOccurred())
+					Expect(address).To(Equal("tcp://" + staticIP + ":" + strconv.Itoa(dataPort)))
+
+					// ensure address works when only a hostname is provided
+					svc.Status.LoadBalancer.Ingress[0].IP = ""
+					address, e = mover.GetDataServiceAddress(svc)
+					Expect(e).NotTo(HaveOccurred())
 					Expect(address).To(Equal("tcp://" + staticHostName + ":" + strconv.Itoa(dataPort)))
+
+					// an ingress without an IP or a hostname has no address
+					svc.Status.LoadBalancer.Ingress[0].Hostname = ""
+					address, e = mover.GetDataServiceAddress(svc)
+					Expect(e).To(HaveOccurred())
+					Expect(address).To(BeEmpty())
+				})
+
+				It("doesn't expose the API by default", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(svc.Spec.Ports).To(HaveLen(1))
+					Expect(svc.Spec.Ports[0].Name).To(Equal(dataPortName))
+				})
+
+				When("the API is exposed", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.ExposeAPI = true
+					})
+
+					It("adds the API port to the data service", func() {
+						deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+
+						svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(svc.Spec.Ports).To(HaveLen(2))
+						Expect(svc.Spec.Ports[1].Name).To(Equal(apiPortName))
+						Expect(svc.Spec.Ports[1].Port).To(Equal(int32(apiPort)))
+					})
+				})
+
+				When("the API is local to the mover's pod", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.PodLocalAPI = true
+					})
+
+					JustBeforeEach(func() {
+						mover.controllerNamespace = "volsync-system"
+					})
+
+					It("only admits VolSync's namespace to the port the GUI listens on", func() {
+						deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(deployment.Spec.Template.Spec.Containers[0].Ports).To(ContainElement(
+							corev1.ContainerPort{Name: apiPortName, ContainerPort: apiPort}))
+						_, err = mover.ensureServices(ctx, &deployment.Spec.Template)
+						Expect(err).NotTo(HaveOccurred())
+
+						networkPolicy := &networkingv1.NetworkPolicy{}
+						Expect(k8sClient.Get(ctx, types.NamespacedName{
+							Name:      "volsync-" + rs.Name,
+							Namespace: ns.Name,
+						}, networkPolicy)).To(Succeed())
+						Expect(networkPolicy.Spec.Ingress).To(HaveLen(2))
+
+						// the data port stays open, as no sources are restricted
+						Expect(networkPolicy.Spec.Ingress[0].From).To(BeEmpty())
+						apiRule := networkPolicy.Spec.Ingress[1]
+						Expect(apiRule.Ports).To(HaveLen(1))
+						Expect(apiRule.Ports[0].Port.StrVal).To(Equal(apiPortName))
+						Expect(apiRule.From).To(HaveLen(1))
+						Expect(apiRule.From[0].PodSelector).To(BeNil())
+						Expect(apiRule.From[0].NamespaceSelector.MatchLabels).To(Equal(
+							map[string]string{"kubernetes.io/metadata.name": "volsync-system"}))
+
+						// the API can't be restricted without knowing where VolSync runs
+						mover.controllerNamespace = ""
+						_, err = mover.ensureServices(ctx, &deployment.Spec.Template)
+						Expect(err).To(HaveOccurred())
+					})
+
+					It("removes the Service for the API", func() {
+						deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+
+						// a Service created before the API was kept local is removed
+						apiSVC, err := mover.ensureAPIService(ctx, &deployment.Spec.Template)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(apiSVC).NotTo(BeNil())
+
+						dataSVC, err := mover.ensureServices(ctx, &deployment.Spec.Template)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(dataSVC).NotTo(BeNil())
+						err = k8sClient.Get(ctx, client.ObjectKeyFromObject(apiSVC), &corev1.Service{})
+						Expect(kerrors.IsNotFound(err)).To(BeTrue())
+					})
+
+					It("reaches the API through the IP of the mover's pod", func() {
+						// no pod is running yet
+						address, err := mover.getMoverPodAPIAddress(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(address).To(BeEmpty())
+
+						pod := &corev1.Pod{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "volsync-" + mover.owner.GetName() + "-pod",
+								Namespace: ns.Name,
+								Labels:    mover.serviceSelector(),
+							},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "syncthing", Image: "syncthing"}},
+							},
+						}
+						Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+						pod.Status.Phase = corev1.PodRunning
+						pod.Status.PodIP = "10.1.2.3"
+						pod.Status.PodIPs = []corev1.PodIP{{IP: "10.1.2.3"}}
+						Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+						address, err = mover.getMoverPodAPIAddress(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(address).To(Equal("https://10.1.2.3:" + strconv.Itoa(apiPort)))
+					})
+				})
+			})
+
+			When("serviceType is NodePort with fixed node ports", func() {
+				const dataNodePort int32 = 30222
+				const apiNodePort int32 = 30384
+
+				BeforeEach(func() {
+					svcType = corev1.ServiceTypeNodePort
+					rs.Spec.Syncthing.ServiceType = &svcType
+					rs.Spec.Syncthing.ExposeAPI = true
+					rs.Spec.Syncthing.DataNodePort = pointer.Int32(dataNodePort)
+					rs.Spec.Syncthing.APINodePort = pointer.Int32(apiNodePort)
+				})
+
+				It("requests the fixed node ports on the service", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+					Expect(svc.Spec.Ports).To(HaveLen(2))
+					Expect(svc.Spec.Ports[0].NodePort).To(Equal(dataNodePort))
+					Expect(svc.Spec.Ports[1].NodePort).To(Equal(apiNodePort))
+				})
+
+				It("advertises the node's address with the data node port", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+
+					// the address is unknown until the pod has been scheduled
+					_, err = mover.GetDataServiceAddress(svc)
+					Expect(err).To(HaveOccurred())
+
+					node := &corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: "syncthing-node-",
+						},
+					}
+					Expect(k8sClient.Create(ctx, node)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, ctx, node)
+					node.Status.Addresses = []corev1.NodeAddress{
+						{Type: corev1.NodeInternalIP, Address: "10.0.0.12"},
+						{Type: corev1.NodeExternalIP, Address: "203.0.113.7"},
+					}
+					Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+					// schedule the mover's pod to the node
+					pod := &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + rs.Name + "-pod",
+							Namespace: ns.Name,
+							Labels:    mover.serviceSelector(),
+						},
+						Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+					}
+					pod.Spec.NodeName = node.Name
+					Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+					Expect(mover.ensureHostNode(ctx)).To(Succeed())
+					address, err := mover.getAdvertisedAddress(svc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(address).To(Equal(fmt.Sprintf("tcp://203.0.113.7:%d", dataNodePort)))
+				})
+			})
+
+			When("the sources allowed to reach the data port are restricted", func() {
+				BeforeEach(func() {
+					rs.Spec.Syncthing.AllowedDataSources = []networkingv1.NetworkPolicyPeer{
+						{IPBlock: &networkingv1.IPBlock{CIDR: "192.168.1.0/24"}},
+						{NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"kubernetes.io/metadata.name": "vandelay"},
+						}},
+					}
+				})
+
+				It("creates a NetworkPolicy with the allowed sources, and removes it once they're unset", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					dataSVC, err := mover.ensureServices(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(dataSVC).NotTo(BeNil())
+
+					networkPolicy := &networkingv1.NetworkPolicy{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name:      "volsync-" + rs.Name,
+						Namespace: ns.Name,
+					}, networkPolicy)).To(Succeed())
+					Expect(metav1.IsControlledBy(networkPolicy, rs)).To(BeTrue())
+					Expect(networkPolicy.Spec.PodSelector.MatchLabels).To(Equal(deployment.Spec.Template.Labels))
+					Expect(networkPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+					Expect(networkPolicy.Spec.Ingress).To(HaveLen(2))
+
+					// only the allowed sources may reach the data port
+					dataRule := networkPolicy.Spec.Ingress[0]
+					Expect(dataRule.Ports).To(HaveLen(1))
+					Expect(dataRule.Ports[0].Port.StrVal).To(Equal(dataPortName))
+					Expect(dataRule.From).To(Equal(rs.Spec.Syncthing.AllowedDataSources))
+
+					// the API port is left open for VolSync
+					apiRule := networkPolicy.Spec.Ingress[1]
+					Expect(apiRule.Ports).To(HaveLen(1))
+					Expect(apiRule.Ports[0].Port.StrVal).To(Equal(apiPortName))
+					Expect(apiRule.From).To(BeEmpty())
+
+					mover.allowedDataSources = nil
+					Expect(mover.ensureNetworkPolicy(ctx, &deployment.Spec.Template)).To(Succeed())
+					err = k8sClient.Get(ctx, client.ObjectKeyFromObject(networkPolicy), networkPolicy)
+					Expect(kerrors.IsNotFound(err)).To(BeTrue())
 				})
 			})
 		})
@@ -397,6 +805,92 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 						return nil
 					}).Should(Succeed())
 				})
+
+				It("removes the mover's workload and the objects derived from it, keeping its volumes and secret", func() {
+					secret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					configPVC, err := mover.ensureConfigPVC(ctx, srcPVC)
+					Expect(err).NotTo(HaveOccurred())
+					sa, err := mover.saHandler.Reconcile(ctx, logger)
+					Expect(err).NotTo(HaveOccurred())
+					podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, secret)
+					Expect(err).NotTo(HaveOccurred())
+					dataService, err := mover.ensureServices(ctx, podTemplate)
+					Expect(err).NotTo(HaveOccurred())
+					mover.allowedDataSources = []networkingv1.NetworkPolicyPeer{
+						{IPBlock: &networkingv1.IPBlock{CIDR: "192.168.1.0/24"}},
+					}
+					Expect(mover.ensureNetworkPolicy(ctx, podTemplate)).To(Succeed())
+					mover.renderConfig = true
+					Expect(mover.ensureRenderedConfig(ctx, &config.Configuration{})).To(Succeed())
+
+					res, err := mover.Cleanup(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.Completed).To(BeTrue())
+
+					for _, obj := range []client.Object{
+						&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: dataService.Name}},
+						&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: mover.getAPIServiceName()}},
+						&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name}},
+						&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name}},
+						&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: mover.getRenderedConfigName()}},
+					} {
+						key := types.NamespacedName{Name: obj.GetName(), Namespace: ns.Name}
+						err := k8sClient.Get(ctx, key, obj)
+						Expect(kerrors.IsNotFound(err)).To(BeTrue(), "%T %s", obj, key.Name)
+					}
+
+					// the data is kept, along with Syncthing's identity and index
+					for _, obj := range []client.Object{
+						&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: srcPVC.Name}},
+						&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secret.Name}},
+						&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: configPVC.Name}},
+					} {
+						key := types.NamespacedName{Name: obj.GetName(), Namespace: ns.Name}
+						Expect(k8sClient.Get(ctx, key, obj)).To(Succeed(), "%T %s", obj, key.Name)
+						Expect(obj.GetDeletionTimestamp()).To(BeNil(), "%T %s", obj, key.Name)
+					}
+
+					// nothing is left to remove
+					res, err = mover.Cleanup(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.Completed).To(BeTrue())
+				})
+			})
+		})
+
+		Context("the owner's UID is annotated", func() {
+			BeforeEach(func() {
+				rs.Spec.Syncthing.AnnotateOwnerUID = true
+			})
+
+			It("annotates the objects created for the mover, until it's disabled", func() {
+				_, _, err := mover.ensureNecessaryResources(ctx)
+				Expect(err).NotTo(HaveOccurred())
+
+				objects := []client.Object{
+					&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name + "-config"}},
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name}},
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name + "-data"}},
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: mover.getAPIServiceName()}},
+					&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name}},
+				}
+				for _, obj := range objects {
+					key := types.NamespacedName{Name: obj.GetName(), Namespace: ns.Name}
+					Expect(k8sClient.Get(ctx, key, obj)).To(Succeed(), "%T %s", obj, key.Name)
+					Expect(obj.GetAnnotations()).To(HaveKeyWithValue(ownerUIDAnnotation, string(rs.UID)),
+						"%T %s", obj, key.Name)
+				}
+
+				// the annotation is removed once it's disabled
+				mover.annotateOwnerUID = false
+				_, _, err = mover.ensureNecessaryResources(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				for _, obj := range objects {
+					key := types.NamespacedName{Name: obj.GetName(), Namespace: ns.Name}
+					Expect(k8sClient.Get(ctx, key, obj)).To(Succeed(), "%T %s", obj, key.Name)
+					Expect(obj.GetAnnotations()).NotTo(HaveKey(ownerUIDAnnotation), "%T %s", obj, key.Name)
+				}
 			})
 		})
 
@@ -469,6 +963,27 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(k8sClient.Get(ctx, types.NamespacedName{
 						Name: configPVC.Name, Namespace: configPVC.Namespace}, configPVC)).To(Succeed())
 				})
+
+				It("defaults to 1Gi, with the data PVC's storage class & access modes", func() {
+					configPVC, err := mover.ensureConfigPVC(ctx, dataPVC)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(configPVC.Spec.Resources.Requests.Storage().Cmp(resource.MustParse("1Gi"))).To(BeZero())
+					Expect(configPVC.Spec.StorageClassName).To(Equal(dataPVC.Spec.StorageClassName))
+					Expect(configPVC.Spec.AccessModes).To(Equal(dataPVC.Spec.AccessModes))
+				})
+			})
+
+			When("configPVC is created concurrently", func() {
+				JustBeforeEach(func() {
+					mover.client = &racingClient{Client: k8sClient}
+				})
+
+				It("VolSync uses the configPVC that was created", func() {
+					configPVC, err := mover.ensureConfigPVC(ctx, srcPVC)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(configPVC).NotTo(BeNil())
+					Expect(configPVC.Name).To(Equal("volsync-" + mover.owner.GetName() + "-config"))
+				})
 			})
 
 			When("config options are provided", func() {
@@ -493,6 +1008,119 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(*config.Spec.Resources.Requests.Storage()).To(Equal(configCapacity))
 				})
 			})
+
+			When("the config PVC is sized automatically", func() {
+				BeforeEach(func() {
+					rs.Spec.Syncthing.AutoSizeConfig = true
+				})
+
+				It("grows with the number of folders, but never shrinks", func() {
+					mover.status.Folders = []volsyncv1alpha1.SyncthingFolderStatus{
+						{ID: "kramerica"}, {ID: "vandelay"}, {ID: "pendant"},
+					}
+					config, err := mover.ensureConfigPVC(ctx, dataPVC)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Spec.Resources.Requests.Storage().Cmp(resource.MustParse("2Gi"))).To(BeZero())
+
+					mover.status.Folders = mover.status.Folders[:1]
+					config, err = mover.ensureConfigPVC(ctx, dataPVC)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Spec.Resources.Requests.Storage().Cmp(resource.MustParse("2Gi"))).To(BeZero())
+				})
+			})
+		})
+
+		Context("the mover's resources are created", func() {
+			It("sets the ReplicationSource as their controller, so they're garbage collected with it", func() {
+				secret, err := mover.ensureSecretAPIKey(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				configPVC, err := mover.ensureConfigPVC(ctx, srcPVC)
+				Expect(err).NotTo(HaveOccurred())
+				sa, err := mover.saHandler.Reconcile(ctx, logger)
+				Expect(err).NotTo(HaveOccurred())
+				podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, secret)
+				Expect(err).NotTo(HaveOccurred())
+				dataService, err := mover.ensureServices(ctx, podTemplate)
+				Expect(err).NotTo(HaveOccurred())
+
+				owned := []client.Object{
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secret.Name}},
+					&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: configPVC.Name}},
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: dataService.Name}},
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: mover.getAPIServiceName()}},
+					&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "volsync-" + rs.Name}},
+				}
+				for _, obj := range owned {
+					key := types.NamespacedName{Name: obj.GetName(), Namespace: ns.Name}
+					Expect(k8sClient.Get(ctx, key, obj)).To(Succeed())
+					Expect(metav1.IsControlledBy(obj, rs)).To(BeTrue(), "%T %s", obj, key.Name)
+				}
+			})
+
+			It("targets the API Service by name, so recreating it with a new IP doesn't break the API client", func() {
+				secret, err := mover.ensureSecretAPIKey(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				configPVC, err := mover.ensureConfigPVC(ctx, srcPVC)
+				Expect(err).NotTo(HaveOccurred())
+				sa, err := mover.saHandler.Reconcile(ctx, logger)
+				Expect(err).NotTo(HaveOccurred())
+				podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, secret)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mover.ensureServices(ctx, podTemplate)
+				Expect(err).NotTo(HaveOccurred())
+
+				apiService := &corev1.Service{}
+				apiServiceKey := types.NamespacedName{Name: mover.getAPIServiceName(), Namespace: ns.Name}
+				Expect(k8sClient.Get(ctx, apiServiceKey, apiService)).To(Succeed())
+				oldIP := apiService.Spec.ClusterIP
+				Expect(oldIP).NotTo(BeEmpty())
+
+				Expect(mover.configureSyncthingAPIClient(secret)).To(Succeed())
+				Expect(mover.apiConfig.APIURL).To(Equal("https://" + mover.getAPIServiceDNS() + ":8384"))
+
+				// the Service is recreated, and assigned a new IP
+				Expect(k8sClient.Delete(ctx, apiService)).To(Succeed())
+				_, err = mover.ensureServices(ctx, podTemplate)
+				Expect(err).NotTo(HaveOccurred())
+				apiService = &corev1.Service{}
+				Expect(k8sClient.Get(ctx, apiServiceKey, apiService)).To(Succeed())
+				Expect(apiService.Spec.ClusterIP).NotTo(BeEmpty())
+
+				// the next reconcile builds a new mover, whose client resolves the Service's current IP
+				m, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{}, rs,
+					true /* privileged */)
+				Expect(err).NotTo(HaveOccurred())
+				nextMover, _ := m.(*Mover)
+				Expect(nextMover).NotTo(BeNil())
+				Expect(nextMover.configureSyncthingAPIClient(secret)).To(Succeed())
+				Expect(nextMover.apiConfig.APIURL).To(Equal(mover.apiConfig.APIURL))
+				Expect(nextMover.apiConfig.APIURL).NotTo(ContainSubstring(oldIP))
+			})
+		})
+
+		When("the API is served under a path prefix", func() {
+			BeforeEach(func() {
+				rs.Spec.Syncthing.APIPathPrefix = pointer.String("/syncthing/")
+			})
+
+			It("prepends the prefix to the requests made to the API", func() {
+				requestedPaths := []string{}
+				proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requestedPaths = append(requestedPaths, r.URL.Path)
+					_, _ = w.Write([]byte("{}"))
+				}))
+				defer proxy.Close()
+
+				secret, err := mover.ensureSecretAPIKey(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				mover.apiConfig.APIURL = proxy.URL
+				Expect(mover.configureSyncthingAPIClient(secret)).To(Succeed())
+				Expect(mover.apiConfig.APIPathPrefix).To(Equal("/syncthing/"))
+
+				_, err = mover.syncthingConnection.FetchConfig(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(requestedPaths).To(Equal([]string{"/syncthing" + api.ConfigEndpoint}))
+			})
 		})
 
 		Context("validate apikey secret", func() {
@@ -529,13 +1157,153 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 				})
 			})
 
-			When("VolSync creates the secret", func() {
-				It("VolSync creates the secret", func() {
-					// create the secret
-					returnedSecret, err := mover.ensureSecretAPIKey(ctx)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(returnedSecret).NotTo(BeNil())
-
+			When("the secret's apikey has been emptied", func() {
+				JustBeforeEach(func() {
+					apiKeys = &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + mover.owner.GetName(),
+							Namespace: ns.Name,
+						},
+						Data: map[string][]byte{
+							apiKeyDataKey:   []byte(""),
+							usernameDataKey: []byte("gcostanza"),
+							passwordDataKey: []byte("bosco"),
+						},
+					}
+					Expect(k8sClient.Create(ctx, apiKeys)).To(Succeed())
+				})
+
+				It("VolSync regenerates the apikey", func() {
+					returnedSecret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(returnedSecret).NotTo(BeNil())
+					Expect(returnedSecret.Data[apiKeyDataKey]).NotTo(BeEmpty())
+
+					// the new apikey is persisted and the other values are kept
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(apiKeys), apiKeys)).To(Succeed())
+					Expect(apiKeys.Data[apiKeyDataKey]).To(Equal(returnedSecret.Data[apiKeyDataKey]))
+					Expect(apiKeys.Data[usernameDataKey]).To(Equal([]byte("gcostanza")))
+					Expect(apiKeys.Data[passwordDataKey]).To(Equal([]byte("bosco")))
+				})
+			})
+
+			When("the apikey is stored under a custom key", func() {
+				BeforeEach(func() {
+					rs.Spec.Syncthing.APIKeySecretKey = pointer.String("syncthing-apikey")
+				})
+
+				It("stores, reads and references the apikey under that key", func() {
+					secret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(secret.Data["syncthing-apikey"]).NotTo(BeEmpty())
+					Expect(secret.Data).NotTo(HaveKey(apiKeyDataKey))
+
+					// the apikey is read from the custom key to access the API
+					Expect(mover.configureSyncthingAPIClient(secret)).To(Succeed())
+					Expect(mover.apiConfig.APIKey).To(Equal(string(secret.Data["syncthing-apikey"])))
+
+					// and Syncthing is given the apikey from the same key
+					configPVC, err := mover.ensureConfigPVC(ctx, srcPVC)
+					Expect(err).NotTo(HaveOccurred())
+					sa, err := mover.saHandler.Reconcile(ctx, logger)
+					Expect(err).NotTo(HaveOccurred())
+					podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, secret)
+					Expect(err).NotTo(HaveOccurred())
+					var apiKeyVar *corev1.EnvVar
+					for i, envVar := range podTemplate.Spec.Containers[0].Env {
+						if envVar.Name == apiKeyEnv {
+							apiKeyVar = &podTemplate.Spec.Containers[0].Env[i]
+						}
+					}
+					Expect(apiKeyVar).NotTo(BeNil())
+					Expect(apiKeyVar.ValueFrom.SecretKeyRef.Name).To(Equal(secret.Name))
+					Expect(apiKeyVar.ValueFrom.SecretKeyRef.Key).To(Equal("syncthing-apikey"))
+				})
+			})
+
+			When("the apikey is due to be rotated", func() {
+				BeforeEach(func() {
+					rs.Spec.Syncthing.APIKeyRotationInterval = &metav1.Duration{Duration: time.Hour}
+				})
+				JustBeforeEach(func() {
+					apiKeys = &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + mover.owner.GetName(),
+							Namespace: ns.Name,
+						},
+						Data: map[string][]byte{
+							apiKeyDataKey:   []byte("my-secret-apikey-do-not-steal"),
+							usernameDataKey: []byte("gcostanza"),
+							passwordDataKey: []byte("bosco"),
+						},
+					}
+					Expect(k8sClient.Create(ctx, apiKeys)).To(Succeed())
+				})
+
+				It("rotates the apikey, and waits for the mover to restart with it", func() {
+					fakeClock := testingclock.NewFakePassiveClock(time.Now())
+					mover.clock = fakeClock
+					mover.apiConfig.APIKey = "my-secret-apikey-do-not-steal"
+
+					// the key is kept until the interval has elapsed
+					returnedSecret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(returnedSecret.Data[apiKeyDataKey]).To(Equal([]byte("my-secret-apikey-do-not-steal")))
+
+					fakeClock.SetTime(fakeClock.Now().Add(2 * time.Hour))
+					returnedSecret, err = mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(returnedSecret.Data[apiKeyDataKey]).NotTo(Equal([]byte("my-secret-apikey-do-not-steal")))
+					Expect(mover.apiConfig.APIKey).To(BeEmpty())
+
+					// the new key and the time of the rotation are persisted
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(apiKeys), apiKeys)).To(Succeed())
+					Expect(apiKeys.Data[apiKeyDataKey]).To(Equal(returnedSecret.Data[apiKeyDataKey]))
+					Expect(apiKeys.Data[usernameDataKey]).To(Equal([]byte("gcostanza")))
+					Expect(apiKeys.Annotations).To(HaveKey(apiKeyRotatedAtAnnotation))
+
+					// the key isn't rotated again until another interval has elapsed
+					rotatedSecret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rotatedSecret.Data[apiKeyDataKey]).To(Equal(returnedSecret.Data[apiKeyDataKey]))
+
+					// Syncthing isn't used while a pod still runs with the old key
+					podTemplate := &corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{apiKeyHashAnnotation: "new-apikey-hash"},
+						},
+					}
+					pod := &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "volsync-" + mover.owner.GetName() + "-old",
+							Namespace:   ns.Name,
+							Labels:      mover.serviceSelector(),
+							Annotations: map[string]string{apiKeyHashAnnotation: "old-apikey-hash"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "syncthing", Image: "syncthing"}},
+						},
+					}
+					Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+					rolledOut, err := mover.isAPIKeyRolledOut(ctx, podTemplate)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rolledOut).To(BeFalse())
+
+					// the old pod is replaced by one running with the new key
+					Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+					rolledOut, err = mover.isAPIKeyRolledOut(ctx, podTemplate)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rolledOut).To(BeTrue())
+				})
+			})
+
+			When("VolSync creates the secret", func() {
+				It("VolSync creates the secret", func() {
+					// create the secret
+					returnedSecret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(returnedSecret).NotTo(BeNil())
+
 					// ensure the secret is created
 					Expect(k8sClient.Get(ctx, types.NamespacedName{
 						Name:      returnedSecret.Name,
@@ -549,6 +1317,45 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 						Expect(returnedSecret.Data[key]).NotTo(BeEmpty())
 					}
 				})
+
+				It("VolSync generates a random apikey, and keeps it across reconciles", func() {
+					secret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					apiKey := string(secret.Data[apiKeyDataKey])
+					Expect(apiKey).NotTo(Equal("password123"))
+					Expect(apiKey).To(HaveLen(32))
+
+					// the existing secret is reused
+					secret, err = mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(secret.Data[apiKeyDataKey])).To(Equal(apiKey))
+				})
+			})
+
+			When("resources are created concurrently", func() {
+				JustBeforeEach(func() {
+					mover.client = &racingClient{Client: k8sClient}
+				})
+
+				It("VolSync retrieves the secret that was created", func() {
+					returnedSecret, err := mover.ensureSecretAPIKey(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(returnedSecret).NotTo(BeNil())
+					Expect(returnedSecret.ResourceVersion).NotTo(BeEmpty())
+					Expect(returnedSecret.Data[apiKeyDataKey]).NotTo(BeEmpty())
+				})
+
+				It("VolSync still creates the services", func() {
+					deployment := &appsv1.Deployment{}
+					svc, err := mover.ensureDataService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(svc).NotTo(BeNil())
+					Expect(svc.ResourceVersion).NotTo(BeEmpty())
+
+					svc, err = mover.ensureAPIService(ctx, &deployment.Spec.Template)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(svc).NotTo(BeNil())
+				})
 			})
 		})
 
@@ -575,7 +1382,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					Expect(err).ToNot(BeNil())
 					Expect(res).To(Equal(cMover.InProgress()))
 
-					syncthing, err := mover.syncthingConnection.Fetch()
+					syncthing, err := mover.syncthingConnection.Fetch(ctx)
 					Expect(err).ToNot(BeNil())
 					Expect(syncthing).To(BeNil())
 
@@ -592,7 +1399,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					}
 
 					syncthing = &api.Syncthing{}
-					err = mover.ensureIsConfigured(apiKeys, syncthing)
+					err = mover.ensureIsConfigured(ctx, apiKeys, syncthing)
 					Expect(err).ToNot(BeNil())
 
 					service := &corev1.Service{
@@ -650,7 +1457,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 				})
 
 				It("Fetches the Latest Info", func() {
-					syncthing, err := mover.syncthingConnection.Fetch()
+					syncthing, err := mover.syncthingConnection.Fetch(ctx)
 					Expect(err).To(BeNil())
 					Expect(syncthing.Configuration.Version).To(Equal(10))
 					Expect(syncthing.SystemStatus.MyID).To(Equal(myID.GoString()))
@@ -663,7 +1470,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							Version: 9,
 						},
 					}
-					err := mover.syncthingConnection.PublishConfig(syncthing.Configuration)
+					err := mover.syncthingConnection.PublishConfig(ctx, syncthing.Configuration)
 					Expect(err).To(BeNil())
 					Expect(syncthingState.Configuration.Version).To(Equal(9))
 				})
@@ -681,11 +1488,11 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 						},
 					}
 					// pull syncthing state from server
-					syncthing, err := mover.syncthingConnection.Fetch()
+					syncthing, err := mover.syncthingConnection.Fetch(ctx)
 					Expect(err).To(BeNil())
 
 					// configure syncthing server w/ local state
-					err = mover.ensureIsConfigured(apiKeys, syncthing)
+					err = mover.ensureIsConfigured(ctx, apiKeys, syncthing)
 					Expect(err).To(BeNil())
 
 					// make sure that our peers can be found on the server
@@ -725,7 +1532,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					}
 
 					// update the mover's status with info from the Syncthing server
-					syncthing, err := mover.syncthingConnection.Fetch()
+					syncthing, err := mover.syncthingConnection.Fetch(ctx)
 					Expect(err).To(BeNil())
 					err = mover.ensureStatusIsUpdated(service, syncthing)
 					Expect(err).To(BeNil())
@@ -742,126 +1549,1415 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					}
 				})
 
-				When("Syncthing has active connections", func() {
-					var device3Config = config.DeviceConfiguration{
-						DeviceID:     device3,
-						Addresses:    []string{"tcp://127.0.0.1:22000"},
-						Name:         "bitcoin-chain-snapshot",
-						IntroducedBy: device2,
-					}
-
+				When("Syncthing doesn't persist its config", func() {
+					var connection *forgetfulConnection
+					var fakeClock *testingclock.FakePassiveClock
 					JustBeforeEach(func() {
-						// add some connections here
-						syncthingState.SystemConnections.Connections = map[string]api.ConnectionStats{
-							myID.GoString(): {
-								Connected: false,
-								Address:   "",
-							},
-							device3.GoString(): {
-								Connected: true,
-								Address:   device3Config.Addresses[0],
+						fakeClock = testingclock.NewFakePassiveClock(time.Now())
+						mover.clock = fakeClock
+						connection = &forgetfulConnection{SyncthingConnection: mover.syncthingConnection}
+						mover.syncthingConnection = connection
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
 							},
 						}
+					})
 
-						// ensure that another-one is in the global config
-						syncthingState.Configuration.SetDevices([]config.DeviceConfiguration{
-							device3Config,
+					It("reports the condition and holds off on updating the config", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(connection.published).To(Equal(1))
+						cond := apimeta.FindStatusCondition(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionConfigNotPersisting)
+						Expect(cond).NotTo(BeNil())
+						Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+						Expect(cond.Reason).To(Equal(volsyncv1alpha1.ConfigNotPersistingReasonDiffer))
+
+						// the config still needs updating, but isn't published again right away
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(connection.published).To(Equal(1))
+
+						// nor just before the hold off is over
+						fakeClock.SetTime(fakeClock.Now().Add(configNotPersistingRetryInterval - time.Second))
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(connection.published).To(Equal(1))
+
+						// once the hold off is over, it's published again
+						fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(connection.published).To(Equal(2))
+
+						// and the condition is cleared once the config sticks
+						mover.syncthingConnection = connection.SyncthingConnection
+						fakeClock.SetTime(fakeClock.Now().Add(configNotPersistingRetryInterval))
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(apimeta.FindStatusCondition(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionConfigNotPersisting)).To(BeNil())
+					})
+				})
+
+				When("the config is updated", func() {
+					var recorder *events.FakeRecorder
+
+					JustBeforeEach(func() {
+						recorder = events.NewFakeRecorder(10)
+						mover.eventRecorder = recorder
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
 							{
-								DeviceID:  myID,
-								Addresses: []string{""},
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
 							},
-						},
-						)
+						}
 					})
 
-					It("adds them to VolSync status", func() {
-						// create a data service that Syncthing will use in status updating
-						fakeDataSVC := corev1.Service{
+					It("reports what was changed through an event", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(recorder.Events).To(Receive(SatisfyAll(
+							HavePrefix(corev1.EventTypeNormal),
+							ContainSubstring(volsyncv1alpha1.EvRSyncthingConfig),
+							ContainSubstring("reconfigured the devices"),
+						)))
+
+						// nothing is reported once Syncthing is configured
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(recorder.Events).NotTo(Receive())
+					})
+
+					It("warns when the Syncthing API fails", func() {
+						mover.syncthingConnection = &failingConnection{SyncthingConnection: mover.syncthingConnection}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).NotTo(Succeed())
+						Expect(recorder.Events).To(Receive(SatisfyAll(
+							HavePrefix(corev1.EventTypeWarning),
+							ContainSubstring(volsyncv1alpha1.EvRSyncthingAPI),
+							ContainSubstring("connection refused"),
+						)))
+						Expect(recorder.Events).NotTo(Receive())
+					})
+				})
+
+				When("an advertised address is provided", func() {
+					var service *corev1.Service
+					BeforeEach(func() {
+						rs.Spec.Syncthing.AdvertisedAddress = pointer.String("tcp://syncthing.example.com:32000")
+					})
+					JustBeforeEach(func() {
+						service = &corev1.Service{
 							ObjectMeta: metav1.ObjectMeta{
 								Name:      "volsync-" + mover.owner.GetName() + "-data",
-								Namespace: ns.Namespace,
+								Namespace: mover.owner.GetNamespace(),
 							},
 							Spec: corev1.ServiceSpec{
 								ClusterIP: "1.2.3.4",
-								Type:      corev1.ServiceTypeClusterIP,
 							},
 						}
+					})
 
-						// expect status to be updated
-						syncthing, err := mover.syncthingConnection.Fetch()
-						Expect(err).To(BeNil())
-						Expect(syncthing).NotTo(BeNil())
-						err = mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)
-						Expect(err).To(BeNil())
-
-						// check that the status contains the new connection
-						Expect(mover.status.Peers).To(HaveLen(1))
-						peer := mover.status.Peers[0]
+					It("overrides the address derived from the data service", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.Address).To(Equal("tcp://syncthing.example.com:32000"))
+					})
 
-						// ensure that volsync properly set these fields
-						// ID should be the other peer's; not the local one
-						Expect(peer.ID).To(Equal(device3.GoString()))
-						Expect(peer.Address).To(Equal(device3Config.Addresses[0]))
-						Expect(peer.Connected).To(BeTrue())
-						Expect(peer.IntroducedBy).To(Equal(device3Config.IntroducedBy.GoString()))
-						Expect(peer.Name).To(Equal(device3Config.Name))
+					It("errors when the address can't be used by Syncthing", func() {
+						mover.advertisedAddress = pointer.String("http://syncthing.example.com")
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).NotTo(Succeed())
 					})
 				})
 
-				Context("VolSync is improperly configuring Syncthing", func() {
+				It("reports the parts of the address alongside it", func() {
+					syncthing, err := mover.syncthingConnection.Fetch(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					mover.hostNode = &corev1.Node{
+						Status: corev1.NodeStatus{
+							Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.12"}},
+						},
+					}
+					services := []corev1.ServiceSpec{
+						{Type: corev1.ServiceTypeClusterIP, ClusterIP: "1.2.3.4"},
+						{Type: corev1.ServiceTypeNodePort, ClusterIP: "1.2.3.4", Ports: []corev1.ServicePort{
+							{Name: dataPortName, Port: dataPort, NodePort: 30222},
+						}},
+						{Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "1.2.3.4"},
+					}
+					expected := []string{"tcp://1.2.3.4:22000", "tcp://10.0.0.12:30222", "tcp://george.costanza:22000"}
+					for i, spec := range services {
+						service := &corev1.Service{Spec: spec}
+						service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "george.costanza"}}
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.Address).To(Equal(expected[i]))
+						Expect(fmt.Sprintf("%s://%s:%d", mover.status.DataScheme, mover.status.DataHost,
+							mover.status.DataPort)).To(Equal(mover.status.Address))
+					}
+
+					// the port is left unset when the advertised address doesn't have one
+					mover.advertisedAddress = pointer.String("quic://syncthing.example.com")
+					Expect(mover.ensureStatusIsUpdated(nil, syncthing)).To(Succeed())
+					Expect(mover.status.DataScheme).To(Equal("quic"))
+					Expect(mover.status.DataHost).To(Equal("syncthing.example.com"))
+					Expect(mover.status.DataPort).To(BeZero())
+				})
+
+				When("the address is mirrored in an annotation", func() {
+					var service *corev1.Service
+					BeforeEach(func() {
+						rs.Spec.Syncthing.AnnotateAddress = true
+					})
 					JustBeforeEach(func() {
+						service = &corev1.Service{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "volsync-" + mover.owner.GetName() + "-data",
+								Namespace: mover.owner.GetNamespace(),
+							},
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
 					})
 
-					When("VolSync adds its own Syncthing instance to the mover's peerList", func() {
-						It("errors", func() {
-							// set the peerlist to itself
-							mover.peerList = []volsyncv1alpha1.SyncthingPeer{
-								{
-									ID:      myID.GoString(),
-									Address: "tcp://127.0.0.1:22000",
-								},
-							}
-							syncthing, err := mover.syncthingConnection.Fetch()
-							Expect(err).NotTo(HaveOccurred())
-							Expect(syncthing).NotTo(BeNil())
-							err = mover.ensureIsConfigured(apiKeys, syncthing)
-							Expect(err).To(HaveOccurred())
-						})
+					It("keeps the annotation in sync with the status", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.ensureAddressAnnotation(ctx)).To(Succeed())
+
+						annotatedRS := &volsyncv1alpha1.ReplicationSource{}
+						Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rs), annotatedRS)).To(Succeed())
+						Expect(annotatedRS.Annotations).To(HaveKeyWithValue(addressAnnotation, mover.status.Address))
+						Expect(rs.ResourceVersion).To(Equal(annotatedRS.ResourceVersion))
+
+						// the annotation follows the address
+						service.Spec.ClusterIP = "5.6.7.8"
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.ensureAddressAnnotation(ctx)).To(Succeed())
+						Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rs), annotatedRS)).To(Succeed())
+						Expect(annotatedRS.Annotations).To(HaveKeyWithValue(addressAnnotation, "tcp://5.6.7.8:22000"))
+
+						// and is removed once the address is no longer mirrored
+						mover.annotateAddress = false
+						Expect(mover.ensureAddressAnnotation(ctx)).To(Succeed())
+						Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rs), annotatedRS)).To(Succeed())
+						Expect(annotatedRS.Annotations).NotTo(HaveKey(addressAnnotation))
 					})
 				})
-			})
-		})
 
-		Context("service account is created", func() {
-			It("creates a new one", func() {
-				// create the SA
-				sa, err := mover.saHandler.Reconcile(ctx, logger)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(sa).NotTo(BeNil())
+				When("a maximum number of peers is set", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.MaxPeers = pointer.Int32(1)
+					})
 
-				// make sure that the SA exists in-cluster
-				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: sa.Name, Namespace: ns.Name}, sa)).To(Succeed())
-			})
-		})
+					It("leaves Syncthing unchanged and reports the problem through a condition", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
+							},
+							{
+								Address: "tcp://127.0.0.2:22000",
+								ID:      device2.GoString(),
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+
+						// nothing should have been published to Syncthing
+						Expect(syncthingState.Configuration.Devices).To(BeEmpty())
+						cond := apimeta.FindStatusCondition(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionTooManyPeers)
+						Expect(cond).NotTo(BeNil())
+						Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+						Expect(cond.Reason).To(Equal(volsyncv1alpha1.TooManyPeersReasonExceeded))
+						Expect(cond.Message).To(ContainSubstring("exceeding the maximum of 1"))
+					})
 
-		Context("A user supplied moverServiceAccount is set in the spec", func() {
-			userSuppliedMoverSvcAccount := "cust-svc-acct"
-			BeforeEach(func() {
-				// Update rsSpec to set our own svc account
-				rs.Spec.Syncthing.MoverServiceAccount = &userSuppliedMoverSvcAccount
-			})
+					It("configures peers up to the maximum", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Devices).To(HaveLen(1))
+						Expect(apimeta.FindStatusCondition(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionTooManyPeers)).To(BeNil())
+					})
+				})
 
-			When("The mover service account does not exist", func() {
-				It("The saHandler should fail to reconcile", func() {
-					sa, err := mover.saHandler.Reconcile(ctx, logger)
-					Expect(sa).To(BeNil())
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(HaveOccurred())
+				When("folder options are provided", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{
+								ID:   "syncthing-folder-id",
+								Path: "/data",
+							},
+						}
+						rs.Spec.Syncthing.Folder = &volsyncv1alpha1.SyncthingFolderSpec{
+							IgnoreDelete: true,
+						}
+					})
+
+					It("writes them to the Syncthing config", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].IgnoreDelete).To(BeTrue())
+					})
 				})
-			})
 
-			When("The mover service account exists", func() {
-				BeforeEach(func() {
+				When("ownership is copied from the parent directory", func() {
+					var recorder *events.FakeRecorder
+
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{
+								ID:   "syncthing-folder-id",
+								Path: "/data",
+							},
+						}
+						rs.Spec.Syncthing.Folder = &volsyncv1alpha1.SyncthingFolderSpec{
+							CopyOwnershipFromParent: true,
+						}
+					})
+
+					JustBeforeEach(func() {
+						recorder = events.NewFakeRecorder(10)
+						mover.eventRecorder = recorder
+					})
+
+					It("writes the option to the Syncthing config", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].CopyOwnershipFromParent).To(BeTrue())
+						Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRSyncthingConfig)))
+						Expect(recorder.Events).NotTo(Receive())
+					})
+
+					It("warns when the mover's capabilities are dropped", func() {
+						mover.privileged = false
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].CopyOwnershipFromParent).To(BeTrue())
+						Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRMoverNotPriv)))
+
+						// the warning is repeated while the mover isn't privileged, although nothing changed
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRMoverNotPriv)))
+					})
+				})
+
+				When("the ownership of files is synced", func() {
+					var recorder *events.FakeRecorder
+
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "syncthing-folder-id", Path: "/data"},
+						}
+						rs.Spec.Syncthing.Folder = &volsyncv1alpha1.SyncthingFolderSpec{
+							SendOwnership: true,
+							SyncOwnership: true,
+						}
+					})
+
+					JustBeforeEach(func() {
+						recorder = events.NewFakeRecorder(10)
+						mover.eventRecorder = recorder
+					})
+
+					It("writes the options to the Syncthing config", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].SendOwnership).To(BeTrue())
+						Expect(syncthingState.Configuration.Folders[0].SyncOwnership).To(BeTrue())
+						Expect(recorder.Events).NotTo(Receive(ContainSubstring(volsyncv1alpha1.EvRMoverNotPriv)))
+					})
+
+					It("warns when the mover isn't privileged", func() {
+						mover.privileged = false
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].SyncOwnership).To(BeTrue())
+						Expect(recorder.Events).To(Receive(SatisfyAll(
+							ContainSubstring(volsyncv1alpha1.EvRMoverNotPriv),
+							ContainSubstring("folder.syncOwnership"),
+						)))
+					})
+
+					It("doesn't warn when ownership is only sent", func() {
+						mover.privileged = false
+						mover.folder.SyncOwnership = false
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Folders[0].SendOwnership).To(BeTrue())
+						Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRSyncthingConfig)))
+						Expect(recorder.Events).NotTo(Receive())
+					})
+				})
+
+				When("the config is rendered for review", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.RenderConfig = true
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "syncthing-folder-id", Path: "/data"},
+						}
+						syncthingState.Configuration.GUI.APIKey = "my-secret-apikey-do-not-steal"
+						syncthingState.Configuration.GUI.Password = "bosco"
+					})
+
+					It("writes the redacted config to a ConfigMap", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{Address: "tcp://127.0.0.1:22000", ID: device1.GoString()},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(mover.ensureRenderedConfig(ctx, &syncthing.Configuration)).To(Succeed())
+
+						configMap := &corev1.ConfigMap{}
+						configMapKey := types.NamespacedName{Name: mover.getRenderedConfigName(), Namespace: ns.Name}
+						Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+						Expect(metav1.IsControlledBy(configMap, rs)).To(BeTrue())
+
+						rendered := configMap.Data[renderedConfigDataKey]
+						Expect(rendered).To(ContainSubstring(device1.GoString()))
+						Expect(rendered).To(ContainSubstring("syncthing-folder-id"))
+						Expect(rendered).To(ContainSubstring(`"apiKey": "REDACTED"`))
+						Expect(rendered).NotTo(ContainSubstring("my-secret-apikey-do-not-steal"))
+						Expect(rendered).NotTo(ContainSubstring("bosco"))
+
+						// the ConfigMap is removed once rendering is disabled
+						mover.renderConfig = false
+						Expect(mover.ensureRenderedConfig(ctx, &syncthing.Configuration)).To(Succeed())
+						Expect(kerrors.IsNotFound(k8sClient.Get(ctx, configMapKey, &corev1.ConfigMap{}))).To(BeTrue())
+					})
+				})
+
+				When("folders are encrypted for untrusted peers", func() {
+					var logs *bytes.Buffer
+
+					BeforeEach(func() {
+						rs.Spec.Syncthing.EncryptionPasswordSecret = pointer.String("untrusted-peers")
+						rs.Spec.Syncthing.RenderConfig = true
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "syncthing-folder-id", Path: "/data"},
+						}
+						Expect(k8sClient.Create(ctx, &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "untrusted-peers",
+								Namespace: ns.Name,
+							},
+							StringData: map[string]string{
+								device2.GoString(): "serenity-now",
+							},
+						})).To(Succeed())
+					})
+
+					JustBeforeEach(func() {
+						// capture every log line, including the most verbose ones
+						logs = &bytes.Buffer{}
+						mover.logger = zap.New(zap.UseDevMode(true), zap.WriteTo(logs), zap.Level(zapcore.Level(-10)))
+					})
+
+					It("sets the passwords from the secret without logging them", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{Address: "tcp://127.0.0.1:22000", ID: device1.GoString()},
+							{Address: "tcp://127.0.0.2:22000", ID: device2.GoString()},
+						}
+						Expect(mover.ensureEncryptionPasswords(ctx)).To(Succeed())
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+
+						passwords := map[string]string{}
+						for _, device := range syncthingState.Configuration.Folders[0].Devices {
+							passwords[device.DeviceID.GoString()] = device.EncryptionPassword
+						}
+						Expect(passwords).To(HaveKeyWithValue(device1.GoString(), ""))
+						Expect(passwords).To(HaveKeyWithValue(device2.GoString(), "serenity-now"))
+
+						Expect(mover.ensureRenderedConfig(ctx, &syncthingState.Configuration)).To(Succeed())
+						configMap := &corev1.ConfigMap{}
+						configMapKey := types.NamespacedName{Name: mover.getRenderedConfigName(), Namespace: ns.Name}
+						Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+						Expect(configMap.Data[renderedConfigDataKey]).NotTo(ContainSubstring("serenity-now"))
+
+						Expect(logs.String()).To(ContainSubstring("updating with config"))
+						Expect(logs.String()).NotTo(ContainSubstring("serenity-now"))
+					})
+				})
+
+				When("stats snapshots are taken", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.StatsSnapshots = &volsyncv1alpha1.SyncthingStatsSnapshotsSpec{
+							Interval: &metav1.Duration{Duration: time.Hour},
+							Retain:   pointer.Int32(2),
+						}
+					})
+
+					It("writes a snapshot every interval, and prunes the oldest past the retained count", func() {
+						fakeClock := testingclock.NewFakePassiveClock(time.Now())
+						mover.clock = fakeClock
+						mover.status.Peers = []volsyncv1alpha1.SyncthingPeerStatus{
+							{ID: device1.GoString(), Address: "tcp://127.0.0.1:22000", Connected: true},
+						}
+						mover.status.Folders = []volsyncv1alpha1.SyncthingFolderStatus{
+							{ID: "syncthing-folder-id", State: "idle"},
+						}
+
+						Expect(mover.ensureStatsSnapshots(ctx)).To(Succeed())
+						snapshots, err := mover.listStatsSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(1))
+						first := snapshots[0].Name
+						Expect(metav1.IsControlledBy(snapshots[0], rs)).To(BeTrue())
+						Expect(snapshots[0].Data[statsSnapshotDataKey]).To(ContainSubstring(device1.GoString()))
+						Expect(snapshots[0].Data[statsSnapshotDataKey]).To(ContainSubstring("syncthing-folder-id"))
+
+						// no snapshot is taken until the interval has passed
+						fakeClock.SetTime(fakeClock.Now().Add(30 * time.Minute))
+						Expect(mover.ensureStatsSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listStatsSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(1))
+
+						fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+						Expect(mover.ensureStatsSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listStatsSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(2))
+						second := snapshots[1].Name
+
+						// the oldest snapshot is pruned once there are more than retained
+						fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+						Expect(mover.ensureStatsSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listStatsSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(2))
+						Expect(snapshots[0].Name).To(Equal(second))
+						Expect(snapshots[1].Name).NotTo(Equal(first))
+						Expect(kerrors.IsNotFound(k8sClient.Get(ctx,
+							types.NamespacedName{Name: first, Namespace: ns.Name}, &corev1.ConfigMap{}))).To(BeTrue())
+
+						// all of the snapshots are removed once they are disabled
+						mover.statsSnapshots = nil
+						Expect(mover.ensureStatsSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listStatsSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(BeEmpty())
+					})
+				})
+
+				When("index snapshots are taken", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.IndexSnapshots = &volsyncv1alpha1.SyncthingIndexSnapshotsSpec{
+							Interval:                &metav1.Duration{Duration: 6 * time.Hour},
+							Retain:                  pointer.Int32(2),
+							VolumeSnapshotClassName: pointer.String("csi-snapclass"),
+						}
+					})
+
+					It("snapshots the config volume every interval, and prunes the oldest past the retained count", func() {
+						fakeClock := testingclock.NewFakePassiveClock(time.Now())
+						mover.clock = fakeClock
+
+						Expect(mover.ensureIndexSnapshots(ctx)).To(Succeed())
+						snapshots, err := mover.listIndexSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(1))
+						first := snapshots[0].Name
+						Expect(metav1.IsControlledBy(snapshots[0], rs)).To(BeTrue())
+						Expect(snapshots[0].Spec.Source.PersistentVolumeClaimName).To(
+							Equal(pointer.String(mover.getConfigPVCName())))
+						Expect(snapshots[0].Spec.VolumeSnapshotClassName).To(Equal(pointer.String("csi-snapclass")))
+
+						// no snapshot is taken until the interval has passed
+						fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+						Expect(mover.ensureIndexSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listIndexSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(1))
+
+						fakeClock.SetTime(fakeClock.Now().Add(6 * time.Hour))
+						Expect(mover.ensureIndexSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listIndexSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(2))
+						second := snapshots[1].Name
+
+						// the oldest snapshot is pruned once there are more than retained
+						fakeClock.SetTime(fakeClock.Now().Add(6 * time.Hour))
+						Expect(mover.ensureIndexSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listIndexSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(2))
+						Expect(snapshots[0].Name).To(Equal(second))
+						Expect(snapshots[1].Name).NotTo(Equal(first))
+						Expect(kerrors.IsNotFound(k8sClient.Get(ctx,
+							types.NamespacedName{Name: first, Namespace: ns.Name}, &snapv1.VolumeSnapshot{}))).To(BeTrue())
+
+						// the snapshots are kept once they are disabled
+						mover.indexSnapshots = nil
+						fakeClock.SetTime(fakeClock.Now().Add(6 * time.Hour))
+						Expect(mover.ensureIndexSnapshots(ctx)).To(Succeed())
+						snapshots, err = mover.listIndexSnapshots(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(snapshots).To(HaveLen(2))
+						Expect(snapshots[0].Name).To(Equal(second))
+					})
+				})
+
+				When("the managed folder is missing from the config", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{}
+						rs.Spec.Syncthing.Folder = &volsyncv1alpha1.SyncthingFolderSpec{
+							IgnoreDelete: true,
+						}
+					})
+
+					It("recreates it, shared with the peers and with the options from the spec", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+
+						Expect(syncthingState.Configuration.Folders).To(HaveLen(1))
+						folder := syncthingState.Configuration.Folders[0]
+						Expect(folder.ID).To(Equal("syncthing-folder-id"))
+						Expect(folder.Path).To(Equal("/data"))
+						Expect(folder.Type).To(Equal(config.FolderTypeSendReceive))
+						Expect(folder.IgnoreDelete).To(BeTrue())
+						Expect(folder.DeviceIDs()).To(ContainElement(device1))
+
+						// it's only recreated once
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(ensureManagedFolder(syncthing)).To(BeFalse())
+					})
+				})
+
+				When("folder management is disabled", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{
+								ID:    "syncthing-folder-id",
+								Label: "managed elsewhere",
+								Path:  "/data",
+							},
+						}
+						rs.Spec.Syncthing.ManageFolders = pointer.Bool(false)
+						rs.Spec.Syncthing.Folder = &volsyncv1alpha1.SyncthingFolderSpec{
+							IgnoreDelete: true,
+						}
+					})
+
+					It("only configures the devices", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{
+								Address: "tcp://127.0.0.1:22000",
+								ID:      device1.GoString(),
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+
+						// the device is added
+						Expect(syncthingState.Configuration.Devices).To(HaveLen(1))
+						Expect(syncthingState.Configuration.Devices[0].DeviceID).To(Equal(device1))
+
+						// but the folder is left as it was
+						Expect(syncthingState.Configuration.Folders).To(HaveLen(1))
+						folder := syncthingState.Configuration.Folders[0]
+						Expect(folder.Label).To(Equal("managed elsewhere"))
+						Expect(folder.IgnoreDelete).To(BeFalse())
+						Expect(folder.Devices).To(BeEmpty())
+					})
+				})
+
+				When("global options are provided", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.Options = &volsyncv1alpha1.SyncthingOptionsSpec{
+							ProgressUpdateIntervalS: pointer.Int32(30),
+						}
+					})
+
+					It("writes them to the Syncthing config", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Options.ProgressUpdateIntervalS).To(Equal(30))
+					})
+
+					When("LAN announcements are configured", func() {
+						BeforeEach(func() {
+							syncthingState.Configuration.Options.AnnounceLANAddresses = true
+							syncthingState.Configuration.Options.LocalAnnEnabled = true
+							rs.Spec.Syncthing.Options.AnnounceLANAddresses = pointer.Bool(false)
+							rs.Spec.Syncthing.Options.LocalAnnounceEnabled = pointer.Bool(false)
+						})
+
+						It("writes them to the Syncthing config", func() {
+							syncthing, err := mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+							Expect(syncthingState.Configuration.Options.AnnounceLANAddresses).To(BeFalse())
+							Expect(syncthingState.Configuration.Options.LocalAnnEnabled).To(BeFalse())
+						})
+					})
+
+					When("custom discovery and relay servers are provided", func() {
+						var discovery, relay string
+
+						BeforeEach(func() {
+							discovery = "https://discovery.example.com:8443/?id=" + device1.GoString()
+							relay = "relay://relay.example.com:22067/?id=" + device2.GoString()
+							rs.Spec.Syncthing.Options.DiscoveryServers = []string{discovery}
+							rs.Spec.Syncthing.Options.RelayServers = []string{relay}
+						})
+
+						It("pushes them in the Syncthing config", func() {
+							syncthing, err := mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+							Expect(syncthingState.Configuration.Options.GlobalAnnEnabled).To(BeTrue())
+							Expect(syncthingState.Configuration.Options.RawGlobalAnnServers).To(Equal([]string{discovery}))
+							Expect(syncthingState.Configuration.Options.RelaysEnabled).To(BeTrue())
+							Expect(syncthingState.Configuration.Options.RawListenAddresses).To(ContainElement(relay))
+						})
+					})
+				})
+
+				When("a name is provided for the local device", func() {
+					BeforeEach(func() {
+						rs.Spec.Syncthing.LocalDeviceName = pointer.String("festivus-backup")
+						syncthingState.Configuration.Devices = []config.DeviceConfiguration{
+							{DeviceID: myID, Name: "volsync-7d9f8b6c5-x2x4z"},
+						}
+					})
+
+					It("names the local device, and corrects it when it drifts", func() {
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Devices[0].DeviceID).To(Equal(myID))
+						Expect(syncthingState.Configuration.Devices[0].Name).To(Equal("festivus-backup"))
+
+						// e.g. Syncthing renames itself after the hostname of a new pod
+						syncthingState.Configuration.Devices[0].Name = "volsync-7d9f8b6c5-k8p2q"
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(syncthingState.Configuration.Devices[0].Name).To(Equal("festivus-backup"))
+					})
+				})
+
+				When("folders contain conflicting files", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "syncthing-folder-id", Path: "/data"},
+						}
+						syncthingState.FolderStatuses = map[string]api.FolderStatus{
+							"syncthing-folder-id": {State: "sync-preparing"},
+						}
+						syncthingState.FolderEntries = map[string][]api.FileEntry{
+							"syncthing-folder-id": {
+								{Name: "contract.txt", Type: "FILE_INFO_TYPE_FILE"},
+								{Name: "contract.sync-conflict-20230101-120000-AIR6LPZ.txt", Type: "FILE_INFO_TYPE_FILE"},
+								{
+									Name: "manuscripts",
+									Type: "FILE_INFO_TYPE_DIRECTORY",
+									Children: []api.FileEntry{
+										{Name: "jerry.sync-conflict-20230102-120000-AIR6LPZ.md", Type: "FILE_INFO_TYPE_FILE"},
+										{Name: "jerry.md", Type: "FILE_INFO_TYPE_FILE"},
+									},
+								},
+							},
+						}
+					})
+
+					It("reports them in the status", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						Expect(mover.status.Folders).To(HaveLen(1))
+						Expect(mover.status.Folders[0].ID).To(Equal("syncthing-folder-id"))
+						Expect(mover.status.Folders[0].State).To(Equal(volsyncv1alpha1.SyncthingFolderStateSyncing))
+						Expect(mover.status.Folders[0].Conflicts).To(Equal(int32(2)))
+						Expect(mover.status.Folders[0].ConflictingFiles).To(ConsistOf(
+							"contract.sync-conflict-20230101-120000-AIR6LPZ.txt",
+							"manuscripts/jerry.sync-conflict-20230102-120000-AIR6LPZ.md",
+						))
+					})
+
+					It("only looks for them near the folder's root", func() {
+						syncthingState.FolderEntries["syncthing-folder-id"] = []api.FileEntry{{
+							Name: "a", Type: "FILE_INFO_TYPE_DIRECTORY", Children: []api.FileEntry{{
+								Name: "b", Type: "FILE_INFO_TYPE_DIRECTORY", Children: []api.FileEntry{{
+									Name: "c", Type: "FILE_INFO_TYPE_DIRECTORY", Children: []api.FileEntry{{
+										Name: "d", Type: "FILE_INFO_TYPE_DIRECTORY", Children: []api.FileEntry{
+											{Name: "deep.sync-conflict-20230101-120000-AIR6LPZ.txt", Type: "FILE_INFO_TYPE_FILE"},
+										},
+									}},
+								}},
+							}},
+						}}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						conflicts, _ := findConflicts("", syncthing.FolderEntries["syncthing-folder-id"])
+						Expect(conflicts).To(BeZero())
+					})
+
+					It("keeps the conflicts previously reported when the folder can't be browsed", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.Folders[0].Conflicts).To(Equal(int32(2)))
+
+						// browsing the folder fails, which leaves a gap in the status rather than failing
+						mover.syncthingConnection = &unbrowsableConnection{SyncthingConnection: mover.syncthingConnection}
+						syncthingState.FolderStatuses["syncthing-folder-id"] = api.FolderStatus{State: "idle"}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(syncthing.FolderEntries).NotTo(HaveKey("syncthing-folder-id"))
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.Folders[0].State).To(Equal(volsyncv1alpha1.SyncthingFolderStateIdle))
+						Expect(mover.status.Folders[0].Conflicts).To(Equal(int32(2)))
+						Expect(mover.status.Folders[0].ConflictingFiles).To(HaveLen(2))
+					})
+				})
+
+				When("folders are shared with different peers", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{
+								ID:   "syncthing-folder-id",
+								Path: "/data",
+								Devices: []config.FolderDeviceConfiguration{
+									{DeviceID: myID},
+									{DeviceID: device1},
+									{DeviceID: device2},
+								},
+							},
+							{
+								ID:      "fan-out",
+								Path:    "/data/fan-out",
+								Devices: []config.FolderDeviceConfiguration{{DeviceID: device2}},
+							},
+							{ID: "unshared", Path: "/data/unshared"},
+						}
+					})
+
+					It("reports the peers each folder is shared with", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						Expect(mover.status.Folders).To(HaveLen(3))
+						Expect(mover.status.Folders[0].SharedWith).To(Equal([]string{
+							device1.GoString(), device2.GoString(),
+						}))
+						Expect(mover.status.Folders[1].SharedWith).To(Equal([]string{device2.GoString()}))
+						Expect(mover.status.Folders[2].SharedWith).To(BeEmpty())
+					})
+				})
+
+				When("folders report their size", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "scanned", Path: "/data"},
+							{ID: "scanning", Path: "/data/scanning"},
+						}
+						syncthingState.FolderStatuses = map[string]api.FolderStatus{
+							"scanned":  {State: "idle", GlobalFiles: 1200, GlobalBytes: 5 << 30},
+							"scanning": {State: "scanning", GlobalFiles: 30, GlobalBytes: 4096},
+						}
+					})
+
+					It("reports the file counts and sizes known so far", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						Expect(mover.status.Folders).To(HaveLen(2))
+						Expect(mover.status.Folders[0].GlobalFiles).To(Equal(int64(1200)))
+						Expect(mover.status.Folders[0].GlobalBytes).To(Equal(int64(5 << 30)))
+						Expect(mover.status.Folders[1].State).To(Equal(volsyncv1alpha1.SyncthingFolderStateScanning))
+						Expect(mover.status.Folders[1].GlobalFiles).To(Equal(int64(30)))
+						Expect(mover.status.Folders[1].GlobalBytes).To(Equal(int64(4096)))
+					})
+				})
+
+				When("the index database grows past its maximum size", func() {
+					var recorder *events.FakeRecorder
+
+					BeforeEach(func() {
+						rs.Spec.Syncthing.MaxIndexSize = resource.NewQuantity(64<<20, resource.BinarySI)
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "syncthing-folder-id", Path: "/data"},
+						}
+						syncthingState.FolderStatuses = map[string]api.FolderStatus{
+							"syncthing-folder-id": {State: "idle", GlobalFiles: 1000, GlobalBytes: 1 << 30},
+						}
+					})
+
+					JustBeforeEach(func() {
+						recorder = events.NewFakeRecorder(10)
+						mover.eventRecorder = recorder
+					})
+
+					It("reports the estimated size and warns once it's exceeded", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.EstimatedIndexBytes).To(BeNumerically(">", 0))
+						Expect(mover.status.EstimatedIndexBytes).To(BeNumerically("<", 64<<20))
+						Expect(recorder.Events).NotTo(Receive())
+
+						// millions of small files make for an oversized index
+						syncthingState.FolderStatuses["syncthing-folder-id"] = api.FolderStatus{
+							State: "idle", GlobalFiles: 5000000, GlobalBytes: 20 << 30,
+						}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(mover.status.EstimatedIndexBytes).To(BeNumerically(">", 64<<20))
+						Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRIndexTooLarge)))
+
+						// the warning isn't repeated while the index stays oversized
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+						Expect(recorder.Events).NotTo(Receive())
+					})
+				})
+
+				When("some folders are in error", func() {
+					BeforeEach(func() {
+						syncthingState.Configuration.Folders = []config.FolderConfiguration{
+							{ID: "healthy", Path: "/data"},
+							{ID: "failed", Path: "/data/failed"},
+							{ID: "pull-errors", Path: "/data/pull-errors"},
+							{ID: "syncing", Path: "/data/syncing"},
+						}
+						syncthingState.FolderStatuses = map[string]api.FolderStatus{
+							"healthy":     {State: "idle"},
+							"failed":      {State: "error"},
+							"pull-errors": {State: "idle", Errors: 3},
+							"syncing":     {State: "syncing"},
+						}
+					})
+
+					It("reports how many are in error", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						Expect(mover.status.Folders).To(HaveLen(4))
+						Expect(mover.status.FoldersInError).To(Equal(int32(2)))
+					})
+
+					It("keeps the previous state of the folders whose status can't be fetched", func() {
+						service := &corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						// the failing folder leaves a gap in the status rather than failing the pass
+						mover.syncthingConnection = &failingFolderConnection{
+							SyncthingConnection: mover.syncthingConnection,
+							folderID:            "failed",
+						}
+						syncthingState.FolderStatuses["syncing"] = api.FolderStatus{State: "idle"}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						mover.fetchFolderDetails(ctx, syncthing)
+						Expect(syncthing.FolderStatuses).NotTo(HaveKey("failed"))
+						Expect(mover.ensureStatusIsUpdated(service, syncthing)).To(Succeed())
+
+						Expect(mover.status.Folders).To(HaveLen(4))
+						Expect(mover.status.Folders[1].ID).To(Equal("failed"))
+						Expect(mover.status.Folders[1].State).To(Equal(volsyncv1alpha1.SyncthingFolderStateError))
+						Expect(mover.status.Folders[3].State).To(Equal(volsyncv1alpha1.SyncthingFolderStateIdle))
+						Expect(mover.status.FoldersInError).To(Equal(int32(2)))
+					})
+				})
+
+				When("Syncthing has active connections", func() {
+					var device3Config = config.DeviceConfiguration{
+						DeviceID:     device3,
+						Addresses:    []string{"tcp://127.0.0.1:22000"},
+						Name:         "bitcoin-chain-snapshot",
+						IntroducedBy: device2,
+					}
+
+					JustBeforeEach(func() {
+						// add some connections here
+						syncthingState.SystemConnections.Connections = map[string]api.ConnectionStats{
+							myID.GoString(): {
+								Connected: false,
+								Address:   "",
+							},
+							device3.GoString(): {
+								Connected: true,
+								Address:   device3Config.Addresses[0],
+							},
+						}
+
+						// ensure that another-one is in the global config
+						syncthingState.Configuration.SetDevices([]config.DeviceConfiguration{
+							device3Config,
+							{
+								DeviceID:  myID,
+								Addresses: []string{""},
+							},
+						},
+						)
+					})
+
+					It("adds them to VolSync status", func() {
+						// create a data service that Syncthing will use in status updating
+						fakeDataSVC := corev1.Service{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "volsync-" + mover.owner.GetName() + "-data",
+								Namespace: ns.Namespace,
+							},
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+								Type:      corev1.ServiceTypeClusterIP,
+							},
+						}
+
+						// expect status to be updated
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).To(BeNil())
+						Expect(syncthing).NotTo(BeNil())
+						err = mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)
+						Expect(err).To(BeNil())
+
+						// check that the status contains the new connection
+						Expect(mover.status.Peers).To(HaveLen(1))
+						peer := mover.status.Peers[0]
+
+						// ensure that volsync properly set these fields
+						// ID should be the other peer's; not the local one
+						Expect(peer.ID).To(Equal(device3.GoString()))
+						Expect(peer.Address).To(Equal(device3Config.Addresses[0]))
+						Expect(peer.Connected).To(BeTrue())
+						Expect(peer.IntroducedBy).To(Equal(device3Config.IntroducedBy.GoString()))
+						Expect(peer.Name).To(Equal(device3Config.Name))
+					})
+
+					It("reports the traffic exchanged with the peer", func() {
+						fakeDataSVC := corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+								Type:      corev1.ServiceTypeClusterIP,
+							},
+						}
+
+						// a peer which has never connected has exchanged nothing
+						syncthingState.SystemConnections.Connections[device3.GoString()] = api.ConnectionStats{
+							Connected: false,
+							Address:   device3Config.Addresses[0],
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+						Expect(mover.status.Peers).To(HaveLen(1))
+						Expect(mover.status.Peers[0].InBytesTotal).To(BeZero())
+						Expect(mover.status.Peers[0].OutBytesTotal).To(BeZero())
+						Expect(mover.status.Peers[0].LastSeen).To(BeNil())
+
+						lastSeen := time.Now().Add(-time.Minute).Truncate(time.Second)
+						syncthingState.SystemConnections.Connections[device3.GoString()] = api.ConnectionStats{
+							TotalStats: api.TotalStats{InBytesTotal: 4096, OutBytesTotal: 1 << 20},
+							Connected:  true,
+							Address:    device3Config.Addresses[0],
+						}
+						syncthingState.DeviceStats = map[string]api.DeviceStats{
+							device3.GoString(): {LastSeen: lastSeen.Format(time.RFC3339)},
+						}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+						Expect(mover.status.Peers).To(HaveLen(1))
+						Expect(mover.status.Peers[0].InBytesTotal).To(Equal(int64(4096)))
+						Expect(mover.status.Peers[0].OutBytesTotal).To(Equal(int64(1 << 20)))
+						Expect(mover.status.Peers[0].LastSeen).NotTo(BeNil())
+						Expect(mover.status.Peers[0].LastSeen.Time.Equal(lastSeen)).To(BeTrue())
+					})
+
+					It("keeps the peers' status when the state of Syncthing is unknown", func() {
+						fakeDataSVC := corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+								Type:      corev1.ServiceTypeClusterIP,
+							},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+						Expect(mover.status.Peers).To(HaveLen(1))
+						Expect(mover.status.Peers[0].Connected).To(BeTrue())
+
+						// the peer isn't reported as disconnected when its connection can't be fetched
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, nil)).NotTo(Succeed())
+						Expect(mover.status.Peers).To(HaveLen(1))
+						Expect(mover.status.Peers[0].Connected).To(BeTrue())
+					})
+
+					It("records whether the peers are reachable when requested", func() {
+						fakeDataSVC := corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+								Type:      corev1.ServiceTypeClusterIP,
+							},
+						}
+						dialer := &fakeDialer{reachable: map[string]bool{"127.0.0.1:22000": true}}
+						mover.dialer = dialer
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{ID: device3.GoString(), Address: device3Config.Addresses[0]},
+						}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+
+						// nothing is dialed unless requested
+						mover.checkPeersAreReachable(ctx)
+						Expect(dialer.dialed).To(BeEmpty())
+						Expect(mover.status.Peers[0].Reachable).To(BeNil())
+
+						mover.checkPeerReachability = true
+						mover.checkPeersAreReachable(ctx)
+						Expect(dialer.dialed).To(Equal([]string{"127.0.0.1:22000"}))
+						Expect(mover.status.Peers[0].Reachable).To(Equal(pointer.Bool(true)))
+
+						// the peer becomes unreachable
+						dialer.reachable = map[string]bool{}
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+						mover.checkPeersAreReachable(ctx)
+						Expect(mover.status.Peers[0].Reachable).To(Equal(pointer.Bool(false)))
+
+						// peers found through discovery can't be dialed
+						mover.peerList[0].Address = "dynamic"
+						syncthingState.SystemConnections.Connections[device3.GoString()] = api.ConnectionStats{}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+						mover.checkPeersAreReachable(ctx)
+						Expect(mover.status.Peers[0].Reachable).To(BeNil())
+						Expect(dialer.dialed).To(HaveLen(2))
+					})
+
+					It("dials the peers concurrently, within a bounded time", func() {
+						dialer := &fakeDialer{
+							reachable: map[string]bool{"127.0.0.1:22000": true},
+							hanging:   map[string]bool{"127.0.0.2:22000": true, "127.0.0.3:22000": true},
+						}
+						mover.dialer = dialer
+						mover.checkPeerReachability = true
+						mover.peerList = nil
+						mover.status.Peers = []volsyncv1alpha1.SyncthingPeerStatus{
+							{ID: device1.GoString(), Address: "tcp://127.0.0.1:22000"},
+							{ID: device2.GoString(), Address: "tcp://127.0.0.2:22000"},
+							{ID: device3.GoString(), Address: "tcp://127.0.0.3:22000"},
+						}
+
+						start := time.Now()
+						mover.checkPeersAreReachable(ctx)
+						// the hanging peers are waited on together rather than one after the other
+						Expect(time.Since(start)).To(BeNumerically("<", 2*peerReachabilityTimeout))
+						Expect(dialer.dialed).To(HaveLen(3))
+						Expect(mover.status.Peers[0].Reachable).To(Equal(pointer.Bool(true)))
+						Expect(mover.status.Peers[1].Reachable).To(Equal(pointer.Bool(false)))
+						Expect(mover.status.Peers[2].Reachable).To(Equal(pointer.Bool(false)))
+					})
+
+					It("accumulates the peer's uptime only while it's connected", func() {
+						fakeClock := testingclock.NewFakePassiveClock(time.Now())
+						mover.clock = fakeClock
+						fakeDataSVC := corev1.Service{
+							Spec: corev1.ServiceSpec{
+								ClusterIP: "1.2.3.4",
+								Type:      corev1.ServiceTypeClusterIP,
+							},
+						}
+						updateStatus := func(connected bool) time.Duration {
+							syncthingState.SystemConnections.Connections[device3.GoString()] = api.ConnectionStats{
+								Connected: connected,
+								Address:   device3Config.Addresses[0],
+							}
+							syncthing, err := mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+							Expect(mover.status.Peers).To(HaveLen(1))
+							Expect(mover.status.Peers[0].ConnectedDuration).NotTo(BeNil())
+							return mover.status.Peers[0].ConnectedDuration.Duration
+						}
+
+						// nothing is accumulated on the first observation
+						Expect(updateStatus(true)).To(BeZero())
+
+						fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+						Expect(updateStatus(true)).To(Equal(time.Minute))
+
+						// the time spent disconnected isn't counted
+						fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+						Expect(updateStatus(false)).To(Equal(time.Minute))
+						fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+						Expect(updateStatus(true)).To(Equal(time.Minute))
+
+						fakeClock.SetTime(fakeClock.Now().Add(2 * time.Minute))
+						Expect(updateStatus(true)).To(Equal(3 * time.Minute))
+					})
+
+					When("a peer hasn't been seen within the stale peer threshold", func() {
+						var recorder *events.FakeRecorder
+						var fakeClock *testingclock.FakePassiveClock
+						lastSeen := time.Date(2023, time.May, 4, 12, 0, 0, 0, time.UTC)
+
+						BeforeEach(func() {
+							rs.Spec.Syncthing.StalePeerThreshold = &metav1.Duration{Duration: time.Hour}
+						})
+
+						JustBeforeEach(func() {
+							syncthingState.SystemConnections.Connections[device3.GoString()] = api.ConnectionStats{
+								Connected: false,
+								Address:   device3Config.Addresses[0],
+							}
+							syncthingState.DeviceStats = map[string]api.DeviceStats{
+								device3.GoString(): {LastSeen: lastSeen.Format(time.RFC3339)},
+							}
+							recorder = events.NewFakeRecorder(10)
+							mover.eventRecorder = recorder
+							fakeClock = testingclock.NewFakePassiveClock(lastSeen.Add(30 * time.Minute))
+							mover.clock = fakeClock
+						})
+
+						It("flags the peer as stale and warns about it once", func() {
+							fakeDataSVC := corev1.Service{
+								Spec: corev1.ServiceSpec{
+									ClusterIP: "1.2.3.4",
+									Type:      corev1.ServiceTypeClusterIP,
+								},
+							}
+							syncthing, err := mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+
+							// the peer isn't stale until the threshold has passed
+							Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+							Expect(mover.status.Peers).To(HaveLen(1))
+							Expect(mover.status.Peers[0].Stale).To(BeFalse())
+							Expect(recorder.Events).NotTo(Receive())
+
+							fakeClock.SetTime(lastSeen.Add(2 * time.Hour))
+							Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+							Expect(mover.status.Peers).To(HaveLen(1))
+							peer := mover.status.Peers[0]
+							Expect(peer.Connected).To(BeFalse())
+							Expect(peer.LastSeen).NotTo(BeNil())
+							Expect(peer.Stale).To(BeTrue())
+							Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRPeerStale)))
+
+							// the warning isn't repeated while the peer stays stale
+							Expect(mover.ensureStatusIsUpdated(&fakeDataSVC, syncthing)).To(Succeed())
+							Expect(mover.status.Peers[0].Stale).To(BeTrue())
+							Expect(recorder.Events).NotTo(Receive())
+						})
+					})
+				})
+
+				Context("VolSync is improperly configuring Syncthing", func() {
+					var recorder *events.FakeRecorder
+
+					JustBeforeEach(func() {
+						recorder = events.NewFakeRecorder(10)
+						mover.eventRecorder = recorder
+					})
+
+					When("VolSync adds its own Syncthing instance to the mover's peerList", func() {
+						It("ignores the entry, and warns about it", func() {
+							// set the peerlist to itself, along with another peer
+							mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+								{
+									ID:      myID.GoString(),
+									Address: "tcp://127.0.0.1:22000",
+								},
+								{
+									ID:      device1.GoString(),
+									Address: "tcp://127.0.0.2:22000",
+								},
+							}
+							syncthing, err := mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(syncthing).NotTo(BeNil())
+							Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+
+							// only the other peer is configured
+							Expect(syncthingState.Configuration.Devices).To(HaveLen(1))
+							Expect(syncthingState.Configuration.Devices[0].DeviceID).To(Equal(device1))
+
+							// the user is told that the entry is ignored
+							Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRSelfPeer)))
+							Expect(recorder.Events).To(Receive(ContainSubstring(volsyncv1alpha1.EvRSyncthingConfig)))
+							Expect(apimeta.IsStatusConditionTrue(rs.Status.Conditions,
+								volsyncv1alpha1.ConditionSelfPeerConfigured)).To(BeTrue())
+
+							// the warning isn't repeated while the entry remains
+							syncthing, err = mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+							Expect(recorder.Events).NotTo(Receive())
+
+							// the condition is removed along with the entry
+							mover.peerList = mover.peerList[1:]
+							syncthing, err = mover.syncthingConnection.Fetch(ctx)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+							Expect(apimeta.FindStatusCondition(rs.Status.Conditions,
+								volsyncv1alpha1.ConditionSelfPeerConfigured)).To(BeNil())
+						})
+					})
+				})
+
+				When("no peers are configured", func() {
+					It("reports it through a condition until a peer is added", func() {
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{}
+						syncthing, err := mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(apimeta.IsStatusConditionTrue(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionNoPeersConfigured)).To(BeTrue())
+
+						// the node itself doesn't count as a peer
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{ID: myID.GoString(), Address: "tcp://127.0.0.1:22000"},
+						}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(apimeta.IsStatusConditionTrue(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionNoPeersConfigured)).To(BeTrue())
+
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{
+							{ID: device1.GoString(), Address: "tcp://127.0.0.2:22000"},
+						}
+						syncthing, err = mover.syncthingConnection.Fetch(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.ensureIsConfigured(ctx, apiKeys, syncthing)).To(Succeed())
+						Expect(apimeta.FindStatusCondition(rs.Status.Conditions,
+							volsyncv1alpha1.ConditionNoPeersConfigured)).To(BeNil())
+					})
+				})
+			})
+		})
+
+		Context("service account is created", func() {
+			It("creates a new one", func() {
+				// create the SA
+				sa, err := mover.saHandler.Reconcile(ctx, logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sa).NotTo(BeNil())
+
+				// make sure that the SA exists in-cluster
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: sa.Name, Namespace: ns.Name}, sa)).To(Succeed())
+			})
+		})
+
+		Context("A user supplied moverServiceAccount is set in the spec", func() {
+			userSuppliedMoverSvcAccount := "cust-svc-acct"
+			BeforeEach(func() {
+				// Update rsSpec to set our own svc account
+				rs.Spec.Syncthing.MoverServiceAccount = &userSuppliedMoverSvcAccount
+			})
+
+			When("The mover service account does not exist", func() {
+				It("The saHandler should fail to reconcile", func() {
+					sa, err := mover.saHandler.Reconcile(ctx, logger)
+					Expect(sa).To(BeNil())
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			When("The mover service account exists", func() {
+				BeforeEach(func() {
 					// Create the svc account
 					userSvcAccount := &corev1.ServiceAccount{
 						ObjectMeta: metav1.ObjectMeta{
@@ -992,58 +3088,501 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 					ts.Close()
 				})
 
-				It("successfully completes a Synchronize", func() {
-					result, err := mover.Synchronize(ctx)
+				It("successfully completes a Synchronize", func() {
+					result, err := mover.Synchronize(ctx)
+
+					// expect no error to have occurred
+					Expect(err).NotTo(HaveOccurred())
+					// synchronization is eternal
+					Expect(result.Completed).To(BeFalse())
+				})
+
+				It("leaves Syncthing untouched while paused, and resumes once unpaused", func() {
+					mover.paused = true
+					result, err := mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Completed).To(BeFalse())
+					Expect(*result.RetryAfter).To(Equal(synchronizeInterval))
+
+					// neither the workload nor the API were touched
+					deployment := &appsv1.Deployment{}
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+						Name: "volsync-" + rs.Name, Namespace: ns.Name}, deployment))).To(BeTrue())
+					Expect(mover.status.ID).To(BeEmpty())
+
+					mover.paused = false
+					_, err = mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name: "volsync-" + rs.Name, Namespace: ns.Name}, deployment)).To(Succeed())
+					Expect(mover.status.ID).To(Equal(myID))
+				})
+
+				It("waits for the configured interval between synchronization passes", func() {
+					mover.syncInterval = &metav1.Duration{Duration: 90 * time.Second}
+					mover.paused = true
+					result, err := mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.RetryAfter).NotTo(BeNil())
+					Expect(*result.RetryAfter).To(Equal(90 * time.Second))
+				})
+
+				It("leaves Syncthing untouched while the paused annotation is set", func() {
+					rs.Annotations = map[string]string{pausedAnnotation: ""}
+					result, err := mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Completed).To(BeFalse())
+					Expect(*result.RetryAfter).To(Equal(synchronizeInterval))
+
+					// neither the workload nor the API were touched
+					deployment := &appsv1.Deployment{}
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+						Name: "volsync-" + rs.Name, Namespace: ns.Name}, deployment))).To(BeTrue())
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+						Name: "volsync-" + rs.Name + "-config", Namespace: ns.Name},
+						&corev1.PersistentVolumeClaim{}))).To(BeTrue())
+					Expect(mover.status.ID).To(BeEmpty())
+
+					delete(rs.Annotations, pausedAnnotation)
+					_, err = mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name: "volsync-" + rs.Name, Namespace: ns.Name}, deployment)).To(Succeed())
+					Expect(mover.status.ID).To(Equal(myID))
+				})
+
+				It("aborts a pass which doesn't complete within the timeout", func() {
+					mover.client = &stuckClient{Client: k8sClient}
+					mover.synchronizeTimeout = &metav1.Duration{Duration: 100 * time.Millisecond}
+
+					start := time.Now()
+					result, err := mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Completed).To(BeFalse())
+					Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+					Expect(apimeta.IsStatusConditionTrue(rs.Status.Conditions,
+						volsyncv1alpha1.ConditionSynchronizeTimedOut)).To(BeTrue())
+
+					// the condition is cleared once a pass completes in time
+					mover.client = k8sClient
+					_, err = mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(apimeta.FindStatusCondition(rs.Status.Conditions,
+						volsyncv1alpha1.ConditionSynchronizeTimedOut)).To(BeNil())
+				})
+
+				It("reports the version of Syncthing's config", func() {
+					_, err := mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mover.status.ConfigVersion).To(Equal(int32(10)))
+
+					// the version changes once Syncthing has migrated its config
+					serverState.Configuration.Version = 37
+					_, err = mover.Synchronize(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mover.status.ConfigVersion).To(Equal(int32(37)))
+				})
+
+				When("peer is added", func() {
+					var peer *volsyncv1alpha1.SyncthingPeer
+					JustBeforeEach(func() {
+						peer = &volsyncv1alpha1.SyncthingPeer{
+							Address:    "tcp://1.2.3.4:5678",
+							ID:         device1,
+							Introducer: false,
+						}
+						mover.peerList = []volsyncv1alpha1.SyncthingPeer{*peer}
+					})
+
+					It("doesn't add duplicates", func() {
+						result, err := mover.Synchronize(ctx)
+
+						// expect no error to have occurred
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result.Completed).To(BeFalse())
+
+						// peer should have been listed in status
+						found := 0
+						for _, p := range mover.status.Peers {
+							if p.ID == peer.ID {
+								found++
+							}
+						}
+						Expect(found).To(Equal(1))
+
+						// make sure no duplicates of peer exist in the syncthing state
+						Expect(len(serverState.Configuration.Devices)).To(Equal(1))
+						Expect(len(serverState.SystemConnections.Connections)).To(Equal(1))
+
+						// run again and ensure no duplicates are added
+						result, err = mover.Synchronize(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result.Completed).To(BeFalse())
+						found = 0
+						for _, p := range mover.status.Peers {
+							if p.ID == peer.ID {
+								found++
+							}
+						}
+						Expect(found).To(Equal(1))
+					})
+
+					It("is only ready once the pod is ready, and the peer is connected", func() {
+						// the mover's pod hasn't started yet
+						_, err := mover.Synchronize(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.status.Ready).To(BeFalse())
+
+						pod := &corev1.Pod{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "volsync-" + mover.owner.GetName() + "-pod",
+								Namespace: ns.Name,
+								Labels:    mover.serviceSelector(),
+							},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "syncthing", Image: "syncthing"}},
+							},
+						}
+						Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+						pod.Status.Conditions = []corev1.PodCondition{
+							{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+						}
+						Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+						// Syncthing is configured and the peer is connected
+						_, err = mover.Synchronize(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.status.Ready).To(BeTrue())
+
+						// the peer disconnects
+						connection := serverState.SystemConnections.Connections[device1]
+						connection.Connected = false
+						serverState.SystemConnections.Connections[device1] = connection
+						_, err = mover.Synchronize(ctx)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mover.status.Ready).To(BeFalse())
+					})
+				})
+			})
+
+			When("the data PVC uses a WaitForFirstConsumer StorageClass", func() {
+				var wffcPVC *corev1.PersistentVolumeClaim
+
+				JustBeforeEach(func() {
+					bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+					storageClass := &storagev1.StorageClass{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: "syncthing-wffc-",
+						},
+						Provisioner:       "example.com/wffc",
+						VolumeBindingMode: &bindingMode,
+					}
+					Expect(k8sClient.Create(ctx, storageClass)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, ctx, storageClass)
+
+					wffcPVC = &corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: "syncthing-wffc-",
+							Namespace:    ns.Name,
+						},
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+							StorageClassName: &storageClass.Name,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceStorage: resource.MustParse("1Gi"),
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(ctx, wffcPVC)).To(Succeed())
+					mover.dataPVCName = &wffcPVC.Name
+				})
+
+				It("creates the workload while the PVC is waiting for it", func() {
+					dataPVC, err := mover.ensureDataPVC(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(dataPVC.Status.Phase).NotTo(Equal(corev1.ClaimBound))
+
+					wffcConfigPVC, err := mover.ensureConfigPVC(ctx, dataPVC)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(wffcConfigPVC.Spec.StorageClassName).To(Equal(wffcPVC.Spec.StorageClassName))
+
+					podTemplate, err := mover.ensureWorkload(ctx, dataPVC, wffcConfigPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(podTemplate).NotTo(BeNil())
+					claims := []string{}
+					for _, volume := range podTemplate.Spec.Volumes {
+						if volume.PersistentVolumeClaim != nil {
+							claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+						}
+					}
+					Expect(claims).To(ConsistOf(wffcPVC.Name, wffcConfigPVC.Name))
+				})
+
+				It("errors once the PVC has lost its volume", func() {
+					wffcPVC.Status.Phase = corev1.ClaimLost
+					Expect(k8sClient.Status().Update(ctx, wffcPVC)).To(Succeed())
+
+					dataPVC, err := mover.ensureDataPVC(ctx)
+					Expect(err).To(HaveOccurred())
+					Expect(dataPVC).To(BeNil())
+				})
+			})
+
+			It("runs Syncthing as a single-replica Deployment which keeps the pod alive", func() {
+				podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, apiSecret)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(podTemplate).NotTo(BeNil())
+
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Name:      "volsync-" + rs.Name,
+					Namespace: ns.Name,
+				}, deployment)).To(Succeed())
+				Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+				// two pods must never share the volumes, even during a rollout
+				Expect(deployment.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
+
+				podSpec := deployment.Spec.Template.Spec
+				Expect(podSpec.RestartPolicy).To(Equal(corev1.RestartPolicyAlways))
+				Expect(podSpec.Containers).To(HaveLen(1))
+				container := podSpec.Containers[0]
+				Expect(container.Ports).To(ContainElements(
+					HaveField("Name", apiPortName),
+					HaveField("Name", dataPortName),
+				))
+				Expect(container.VolumeMounts).To(ContainElements(
+					corev1.VolumeMount{Name: mover.configVolumeName, MountPath: configDirMountPath},
+					corev1.VolumeMount{Name: mover.dataVolumeName, MountPath: dataDirMountPath},
+				))
+				Expect(container.Env).To(ContainElement(HaveField("Name", apiKeyEnv)))
+				Expect(podUsesClaims(&podSpec, srcPVC)).To(BeTrue())
+				Expect(podUsesClaims(&podSpec, configPVC)).To(BeTrue())
+
+				// no Job is created
+				Expect(kerrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+					Name:      "volsync-" + rs.Name,
+					Namespace: ns.Name,
+				}, &batchv1.Job{}))).To(BeTrue())
+			})
+
+			When("a Job from the legacy mover still holds the volumes", func() {
+				var legacyJob *batchv1.Job
+
+				JustBeforeEach(func() {
+					legacyJob = &batchv1.Job{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + rs.Name,
+							Namespace: ns.Name,
+						},
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{
+										{Name: "syncthing", Image: "quay.io/backube/volsync:latest"},
+									},
+									Volumes: []corev1.Volume{
+										{
+											Name: "data",
+											VolumeSource: corev1.VolumeSource{
+												PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+													ClaimName: srcPVC.Name,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}
+					Expect(ctrl.SetControllerReference(rs, legacyJob, k8sClient.Scheme())).To(Succeed())
+					Expect(k8sClient.Create(ctx, legacyJob)).To(Succeed())
+				})
+
+				It("removes the Job before creating the Deployment", func() {
+					deploymentKey := types.NamespacedName{Name: "volsync-" + rs.Name, Namespace: ns.Name}
+
+					podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(podTemplate).To(BeNil())
+
+					// the Job is being deleted, and the Deployment waits for it to release the volumes
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(legacyJob), legacyJob)).To(Succeed())
+					Expect(legacyJob.DeletionTimestamp).NotTo(BeNil())
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{}))).To(BeTrue())
+
+					// there's no garbage collector in the test env, so finish the foreground deletion by hand
+					legacyJob.Finalizers = nil
+					Expect(k8sClient.Update(ctx, legacyJob)).To(Succeed())
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(legacyJob),
+						&batchv1.Job{}))).To(BeTrue())
+
+					podTemplate, err = mover.ensureWorkload(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(podTemplate).NotTo(BeNil())
+					Expect(k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})).To(Succeed())
+				})
+			})
+
+			When("the data port is bound on the host", func() {
+				var node *corev1.Node
+
+				BeforeEach(func() {
+					rs.Spec.Syncthing.UseHostPort = true
+				})
+
+				JustBeforeEach(func() {
+					node = &corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: "syncthing-node-",
+						},
+					}
+					Expect(k8sClient.Create(ctx, node)).To(Succeed())
+					node.Labels = map[string]string{corev1.LabelHostname: node.Name}
+					Expect(k8sClient.Update(ctx, node)).To(Succeed())
+					node.Status.Addresses = []corev1.NodeAddress{
+						{Type: corev1.NodeHostName, Address: node.Name},
+						{Type: corev1.NodeInternalIP, Address: "10.0.0.12"},
+					}
+					Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, ctx, node)
+				})
+
+				It("sets the hostPort and advertises the node's address", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					ports := deployment.Spec.Template.Spec.Containers[0].Ports
+					Expect(ports[1].Name).To(Equal(dataPortName))
+					Expect(ports[1].HostPort).To(Equal(int32(dataPort)))
+
+					// the address is unknown until the pod has been scheduled
+					_, err = mover.getAdvertisedAddress(nil)
+					Expect(err).To(HaveOccurred())
+
+					// schedule the mover's pod to the node
+					pod := &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + rs.Name + "-pod",
+							Namespace: ns.Name,
+							Labels:    mover.serviceSelector(),
+						},
+						Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+					}
+					pod.Spec.NodeName = node.Name
+					Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+					Expect(mover.ensureHostNode(ctx)).To(Succeed())
+					address, err := mover.getAdvertisedAddress(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(address).To(Equal("tcp://10.0.0.12:" + strconv.Itoa(dataPort)))
+
+					// the mover is kept on the node so the address stays stable
+					deployment, err = mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(deployment.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{
+						corev1.LabelHostname: node.Name,
+					}))
+				})
+
+				It("ignores the pods being terminated", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+
+					// the previous pod is still terminating on the node the mover has left
+					terminating := &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:       "volsync-" + rs.Name + "-old",
+							Namespace:  ns.Name,
+							Labels:     mover.serviceSelector(),
+							Finalizers: []string{"volsync.backube/test"},
+						},
+						Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+					}
+					terminating.Spec.NodeName = "departed-node"
+					Expect(k8sClient.Create(ctx, terminating)).To(Succeed())
+					Expect(k8sClient.Delete(ctx, terminating)).To(Succeed())
+					DeferCleanup(func() {
+						Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(terminating), terminating)).To(Succeed())
+						terminating.Finalizers = nil
+						Expect(k8sClient.Update(ctx, terminating)).To(Succeed())
+					})
+
+					Expect(mover.ensureHostNode(ctx)).To(Succeed())
+					Expect(mover.hostNode).To(BeNil())
+
+					pod := &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "volsync-" + rs.Name + "-pod",
+							Namespace: ns.Name,
+							Labels:    mover.serviceSelector(),
+						},
+						Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+					}
+					pod.Spec.NodeName = node.Name
+					Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+					Expect(mover.ensureHostNode(ctx)).To(Succeed())
+					Expect(mover.hostNode).NotTo(BeNil())
+					Expect(mover.hostNode.Name).To(Equal(node.Name))
+				})
+			})
+
+			When("the mover runs as a StatefulSet", func() {
+				BeforeEach(func() {
+					workloadType := volsyncv1alpha1.SyncthingWorkloadStatefulSet
+					rs.Spec.Syncthing.WorkloadType = &workloadType
+				})
 
-					// expect no error to have occurred
+				It("creates the StatefulSet and the headless service", func() {
+					podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, apiSecret)
 					Expect(err).NotTo(HaveOccurred())
-					// synchronization is eternal
-					Expect(result.Completed).To(BeFalse())
-				})
+					Expect(podTemplate).NotTo(BeNil())
 
-				When("peer is added", func() {
-					var peer *volsyncv1alpha1.SyncthingPeer
-					JustBeforeEach(func() {
-						peer = &volsyncv1alpha1.SyncthingPeer{
-							Address:    "tcp://1.2.3.4:5678",
-							ID:         device1,
-							Introducer: false,
-						}
-						mover.peerList = []volsyncv1alpha1.SyncthingPeer{*peer}
-					})
+					statefulSet := &appsv1.StatefulSet{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name:      "volsync-" + rs.Name,
+						Namespace: ns.Name,
+					}, statefulSet)).To(Succeed())
+					Expect(*statefulSet.Spec.Replicas).To(Equal(int32(1)))
+					Expect(statefulSet.Spec.ServiceName).To(Equal(mover.getPeerServiceName()))
+					Expect(statefulSet.Spec.Template.Spec.Containers[0].Name).To(Equal("syncthing"))
 
-					It("doesn't add duplicates", func() {
-						result, err := mover.Synchronize(ctx)
+					peerService := &corev1.Service{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{
+						Name:      mover.getPeerServiceName(),
+						Namespace: ns.Name,
+					}, peerService)).To(Succeed())
+					Expect(peerService.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+					Expect(peerService.Spec.Selector).To(Equal(statefulSet.Spec.Template.Labels))
 
-						// expect no error to have occurred
-						Expect(err).NotTo(HaveOccurred())
-						Expect(result.Completed).To(BeFalse())
+					// no Deployment is created
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+						Name:      "volsync-" + rs.Name,
+						Namespace: ns.Name,
+					}, &appsv1.Deployment{}))).To(BeTrue())
 
-						// peer should have been listed in status
-						found := 0
-						for _, p := range mover.status.Peers {
-							if p.ID == peer.ID {
-								found++
-							}
-						}
-						Expect(found).To(Equal(1))
+					// the pod's stable DNS name is advertised to peers
+					address, err := mover.getAdvertisedAddress(nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(address).To(Equal(fmt.Sprintf("tcp://volsync-%s-0.%s.%s:%d",
+						rs.Name, mover.getPeerServiceName(), ns.Name, dataPort)))
+				})
 
-						// make sure no duplicates of peer exist in the syncthing state
-						Expect(len(serverState.Configuration.Devices)).To(Equal(1))
-						Expect(len(serverState.SystemConnections.Connections)).To(Equal(1))
+				It("waits for a previous Deployment to be removed", func() {
+					deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
 
-						// run again and ensure no duplicates are added
-						result, err = mover.Synchronize(ctx)
-						Expect(err).NotTo(HaveOccurred())
-						Expect(result.Completed).To(BeFalse())
-						found = 0
-						for _, p := range mover.status.Peers {
-							if p.ID == peer.ID {
-								found++
-							}
-						}
-						Expect(found).To(Equal(1))
-					})
+					podTemplate, err := mover.ensureWorkload(ctx, srcPVC, configPVC, sa, apiSecret)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(podTemplate).To(BeNil())
+
+					// the Deployment is being deleted, and the StatefulSet isn't created yet
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+					Expect(deployment.DeletionTimestamp).NotTo(BeNil())
+					Expect(kerrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(deployment),
+						&appsv1.StatefulSet{}))).To(BeTrue())
 				})
 			})
 
@@ -1120,8 +3659,8 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 
 						// make sure that configPVC and srcPVC are referenced in the deployment
 						necessaryVolumeMounts := []string{
-							configVolumeName,
-							dataVolumeName,
+							mover.configVolumeName,
+							mover.dataVolumeName,
 							certVolumeName,
 						}
 						found := 0
@@ -1137,10 +3676,10 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 
 						checked := 0
 						for _, volume := range deployment.Spec.Template.Spec.Volumes {
-							if volume.Name == configVolumeName {
+							if volume.Name == mover.configVolumeName {
 								Expect(volume.PersistentVolumeClaim.ClaimName).To(Equal(configPVC.Name))
 								checked++
-							} else if volume.Name == dataVolumeName {
+							} else if volume.Name == mover.dataVolumeName {
 								Expect(volume.PersistentVolumeClaim.ClaimName).To(Equal(srcPVC.Name))
 								checked++
 							} else if volume.Name == certVolumeName {
@@ -1166,9 +3705,9 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 
 						// make sure that both deployment's specified volumes are being mounted by the container
 						for _, mount := range stContainer.VolumeMounts {
-							if mount.Name == configVolumeName {
+							if mount.Name == mover.configVolumeName {
 								Expect(mount.MountPath).To(Equal(configDirMountPath))
-							} else if mount.Name == dataVolumeName {
+							} else if mount.Name == mover.dataVolumeName {
 								Expect(mount.MountPath).To(Equal(dataDirMountPath))
 							} else if mount.Name == certVolumeName {
 								Expect(mount.MountPath).To(Equal(certDirMountPath))
@@ -1210,6 +3749,385 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							Expect(envVars).To(ContainElement(corev1.EnvVar{Name: "no_proxy", Value: noProxy}))
 						})
 					})
+					Context("Volume names", func() {
+						BeforeEach(func() {
+							rs.Spec.Syncthing.ConfigVolumeName = pointer.String("custom-config")
+							rs.Spec.Syncthing.DataVolumeName = pointer.String("custom-data")
+						})
+
+						It("Should use the custom names for the volumes and their mounts", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+
+							volumeNames := []string{}
+							for _, volume := range deployment.Spec.Template.Spec.Volumes {
+								volumeNames = append(volumeNames, volume.Name)
+							}
+							Expect(volumeNames).To(ConsistOf("custom-config", "custom-data", certVolumeName))
+
+							mounts := map[string]string{}
+							for _, mount := range deployment.Spec.Template.Spec.Containers[0].VolumeMounts {
+								mounts[mount.Name] = mount.MountPath
+							}
+							Expect(mounts).To(HaveKeyWithValue("custom-config", configDirMountPath))
+							Expect(mounts).To(HaveKeyWithValue("custom-data", dataDirMountPath))
+						})
+					})
+					It("Should roll out a new pod when the API key changes", func() {
+						deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+						apiKeyHash := deployment.Spec.Template.Annotations[apiKeyHashAnnotation]
+						Expect(apiKeyHash).NotTo(BeEmpty())
+
+						// the pod template is unchanged while the API key is the same
+						deployment, err = mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(deployment.Spec.Template.Annotations[apiKeyHashAnnotation]).To(Equal(apiKeyHash))
+
+						apiSecret.Data[apiKeyDataKey] = []byte("a-brand-new-apikey")
+						deployment, err = mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(deployment.Spec.Template.Annotations[apiKeyHashAnnotation]).NotTo(Equal(apiKeyHash))
+					})
+					Context("Scheduler name", func() {
+						It("Should use the default scheduler by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(deployment.Spec.Template.Spec.SchedulerName).To(Equal(corev1.DefaultSchedulerName))
+						})
+
+						When("a scheduler name is provided", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.SchedulerName = pointer.String("my-custom-scheduler")
+							})
+
+							It("Should set the scheduler name on the pod template", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment.Spec.Template.Spec.SchedulerName).To(Equal("my-custom-scheduler"))
+							})
+						})
+					})
+					Context("Service links", func() {
+						It("Should not inject the Services' env vars by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(deployment.Spec.Template.Spec.EnableServiceLinks).To(Equal(pointer.Bool(false)))
+						})
+
+						When("service links are enabled", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.EnableServiceLinks = pointer.Bool(true)
+							})
+
+							It("Should set enableServiceLinks on the pod template", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment.Spec.Template.Spec.EnableServiceLinks).To(Equal(pointer.Bool(true)))
+							})
+						})
+					})
+					Context("Mover image", func() {
+						When("an image is provided", func() {
+							const mirroredImage = "registry.example.com/volsync@sha256:" +
+								"7f0d1cfe0e2e8a1d4c0d4c9e8c3e29f8bcb0a7c1f4e2b9d3a6c5e8f1a2b3c4d5"
+
+							It("Should override the image the controller is configured with", func() {
+								rs.Spec.Syncthing.MoverImage = pointer.String(mirroredImage)
+								m, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{},
+									rs, false /* privileged */)
+								Expect(err).NotTo(HaveOccurred())
+								unprivilegedMover, _ := m.(*Mover)
+								Expect(unprivilegedMover).NotTo(BeNil())
+
+								deployment, err := unprivilegedMover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								stContainer := deployment.Spec.Template.Spec.Containers[0]
+								Expect(stContainer.Image).To(Equal(mirroredImage))
+								// the image is pinned by digest, so it's only pulled when missing
+								Expect(stContainer.ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+							})
+
+							It("Should be rejected while the mover is privileged", func() {
+								rs.Spec.Syncthing.MoverImage = pointer.String(mirroredImage)
+								m, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{},
+									rs, true /* privileged */)
+								Expect(m).To(BeNil())
+								Expect(err).To(MatchError(ContainSubstring("privileged")))
+							})
+
+							It("Should be rejected when it isn't a valid image reference", func() {
+								rs.Spec.Syncthing.MoverImage = pointer.String("registry.example.com/Vol Sync")
+								m, err := commonBuilderForTestSuite.FromSource(k8sClient, logger, &events.FakeRecorder{},
+									rs, false /* privileged */)
+								Expect(m).To(BeNil())
+								Expect(err).To(MatchError(ContainSubstring("not a valid image reference")))
+							})
+						})
+					})
+					Context("Image pull policy", func() {
+						It("Should derive the policy from the image by default", func() {
+							mover.containerImage = "quay.io/backube/volsync:latest"
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+						})
+
+						When("a pull policy is provided", func() {
+							BeforeEach(func() {
+								policy := corev1.PullNever
+								rs.Spec.Syncthing.ImagePullPolicy = &policy
+							})
+
+							It("Should use it for the Syncthing container", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullNever))
+							})
+						})
+					})
+					Context("Mover resources", func() {
+						It("Should limit the memory of the container by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							resources := deployment.Spec.Template.Spec.Containers[0].Resources
+							Expect(resources.Requests).To(BeEmpty())
+							Expect(resources.Limits).To(HaveLen(1))
+							Expect(resources.Limits.Memory().Cmp(resource.MustParse("1Gi"))).To(BeZero())
+						})
+
+						When("resources are provided", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.MoverResources = &corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										corev1.ResourceCPU:    resource.MustParse("500m"),
+										corev1.ResourceMemory: resource.MustParse("2Gi"),
+									},
+									Limits: corev1.ResourceList{
+										corev1.ResourceMemory: resource.MustParse("4Gi"),
+									},
+								}
+							})
+
+							It("Should use them for the Syncthing container", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								resources := deployment.Spec.Template.Spec.Containers[0].Resources
+								Expect(resources.Requests.Cpu().Cmp(resource.MustParse("500m"))).To(BeZero())
+								Expect(resources.Requests.Memory().Cmp(resource.MustParse("2Gi"))).To(BeZero())
+								Expect(resources.Limits.Memory().Cmp(resource.MustParse("4Gi"))).To(BeZero())
+								// the CPU is left unlimited, as only the requests set it
+								Expect(resources.Limits).NotTo(HaveKey(corev1.ResourceCPU))
+							})
+						})
+					})
+					Context("Startup health check", func() {
+						It("Should not have a postStart hook by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(deployment.Spec.Template.Spec.Containers[0].Lifecycle).To(BeNil())
+						})
+
+						It("Should only mark the pod as ready once the API is healthy", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+
+							probe := deployment.Spec.Template.Spec.Containers[0].ReadinessProbe
+							Expect(probe).NotTo(BeNil())
+							Expect(probe.HTTPGet).NotTo(BeNil())
+							Expect(probe.HTTPGet.Path).To(Equal("/rest/noauth/health"))
+							Expect(probe.HTTPGet.Port).To(Equal(intstr.FromString(apiPortName)))
+							Expect(probe.HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTPS))
+						})
+
+						When("a startup health timeout is provided", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.StartupHealthTimeoutSeconds = pointer.Int32(120)
+							})
+
+							It("Should wait for Syncthing to be healthy in a postStart hook", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+
+								stContainer := deployment.Spec.Template.Spec.Containers[0]
+								Expect(stContainer.Lifecycle).NotTo(BeNil())
+								Expect(stContainer.Lifecycle.PostStart).NotTo(BeNil())
+								Expect(stContainer.Lifecycle.PostStart.Exec.Command).To(
+									Equal([]string{"/mover-syncthing/entry.sh", "wait-healthy"}))
+								Expect(stContainer.Env).To(ContainElement(corev1.EnvVar{Name: healthTimeoutEnv, Value: "120"}))
+								// the image checks the standard endpoint by default
+								for _, env := range stContainer.Env {
+									Expect(env.Name).NotTo(Equal(healthPathEnv))
+								}
+							})
+
+							When("a custom health check path is provided", func() {
+								BeforeEach(func() {
+									rs.Spec.Syncthing.HealthCheckPath = pointer.String("/proxy/health")
+								})
+
+								It("Should pass the path to the health check", func() {
+									deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+									Expect(err).NotTo(HaveOccurred())
+									Expect(deployment.Spec.Template.Spec.Containers[0].Env).To(ContainElement(
+										corev1.EnvVar{Name: healthPathEnv, Value: "/proxy/health"}))
+									Expect(deployment.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Path).To(
+										Equal("/proxy/health"))
+								})
+							})
+						})
+					})
+					Context("Termination message policy", func() {
+						It("Should fall back to the logs on error by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(deployment.Spec.Template.Spec.Containers[0].TerminationMessagePolicy).To(
+								Equal(corev1.TerminationMessageFallbackToLogsOnError))
+						})
+
+						When("a termination message policy is provided", func() {
+							BeforeEach(func() {
+								policy := corev1.TerminationMessageReadFile
+								rs.Spec.Syncthing.TerminationMessagePolicy = &policy
+							})
+
+							It("Should set the policy on the container", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment.Spec.Template.Spec.Containers[0].TerminationMessagePolicy).To(
+									Equal(corev1.TerminationMessageReadFile))
+							})
+						})
+					})
+					Context("Debug options", func() {
+						It("Should run Syncthing without a TTY by default", func() {
+							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+							Expect(err).NotTo(HaveOccurred())
+							stContainer := deployment.Spec.Template.Spec.Containers[0]
+							Expect(stContainer.Stdin).To(BeFalse())
+							Expect(stContainer.TTY).To(BeFalse())
+							Expect(stContainer.Command).To(Equal([]string{"/mover-syncthing/entry.sh"}))
+						})
+
+						When("a TTY is requested", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.Debug = &volsyncv1alpha1.SyncthingDebugSpec{TTY: true}
+							})
+
+							It("Should keep stdin open and allocate a TTY, while still running Syncthing", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								stContainer := deployment.Spec.Template.Spec.Containers[0]
+								Expect(stContainer.Stdin).To(BeTrue())
+								Expect(stContainer.TTY).To(BeTrue())
+								Expect(stContainer.Command).To(Equal([]string{"/mover-syncthing/entry.sh"}))
+							})
+						})
+
+						When("the container is asked to sleep", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.Debug = &volsyncv1alpha1.SyncthingDebugSpec{Sleep: true}
+								rs.Spec.Syncthing.StartupHealthTimeoutSeconds = pointer.Int32(120)
+							})
+
+							It("Should sleep instead of running Syncthing", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								stContainer := deployment.Spec.Template.Spec.Containers[0]
+								Expect(stContainer.Command).To(Equal([]string{"sleep", "infinity"}))
+								Expect(stContainer.Args).To(BeEmpty())
+								// Syncthing never becomes healthy, so neither the container nor the pod waits for it
+								Expect(stContainer.Lifecycle).To(BeNil())
+								Expect(stContainer.ReadinessProbe).To(BeNil())
+								Expect(stContainer.TTY).To(BeFalse())
+							})
+						})
+					})
+					Context("API certificate", func() {
+						When("a certificate secret is provided", func() {
+							var certSecret *corev1.Secret
+
+							BeforeEach(func() {
+								rs.Spec.Syncthing.APICertificateSecret = pointer.String("my-api-cert")
+							})
+							JustBeforeEach(func() {
+								certPEM, keyPEM, err := generateTLSCertificatesForSyncthing(mover.getAPIServiceDNS())
+								Expect(err).NotTo(HaveOccurred())
+								certSecret = &corev1.Secret{
+									ObjectMeta: metav1.ObjectMeta{
+										Name:      "my-api-cert",
+										Namespace: ns.Name,
+									},
+									Type: corev1.SecretTypeTLS,
+									Data: map[string][]byte{
+										corev1.TLSCertKey:       certPEM.Bytes(),
+										corev1.TLSPrivateKeyKey: keyPEM.Bytes(),
+									},
+								}
+								Expect(k8sClient.Create(ctx, certSecret)).To(Succeed())
+							})
+
+							It("Should mount the provided certificate for the API", func() {
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+
+								var certVolume *corev1.Volume
+								for i, volume := range deployment.Spec.Template.Spec.Volumes {
+									if volume.Name == certVolumeName {
+										certVolume = &deployment.Spec.Template.Spec.Volumes[i]
+									}
+								}
+								Expect(certVolume).NotTo(BeNil())
+								Expect(certVolume.Secret.SecretName).To(Equal(certSecret.Name))
+								Expect(certVolume.Secret.Items).To(ConsistOf(
+									corev1.KeyToPath{Key: corev1.TLSPrivateKeyKey, Path: httpsKeyPath},
+									corev1.KeyToPath{Key: corev1.TLSCertKey, Path: httpsCertPath},
+								))
+							})
+
+							It("Should roll out a new pod when the certificate is rotated", func() {
+								Expect(mover.ensureAPICertificate(ctx)).To(Succeed())
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								certHash := deployment.Spec.Template.Annotations[apiCertHashAnnotation]
+								Expect(certHash).NotTo(BeEmpty())
+
+								certPEM, keyPEM, err := generateTLSCertificatesForSyncthing(mover.getAPIServiceDNS())
+								Expect(err).NotTo(HaveOccurred())
+								certSecret.Data[corev1.TLSCertKey] = certPEM.Bytes()
+								certSecret.Data[corev1.TLSPrivateKeyKey] = keyPEM.Bytes()
+								Expect(k8sClient.Update(ctx, certSecret)).To(Succeed())
+
+								Expect(mover.ensureAPICertificate(ctx)).To(Succeed())
+								deployment, err = mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment.Spec.Template.Annotations[apiCertHashAnnotation]).NotTo(BeEmpty())
+								Expect(deployment.Spec.Template.Annotations[apiCertHashAnnotation]).NotTo(Equal(certHash))
+							})
+
+							It("Should trust the provided certificate when connecting to the API", func() {
+								Expect(mover.ensureAPICertificate(ctx)).To(Succeed())
+								tlsConfig, err := mover.loadTLSConfigFromSecret(apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+
+								block, _ := pem.Decode(certSecret.Data[corev1.TLSCertKey])
+								Expect(block).NotTo(BeNil())
+								cert, err := x509.ParseCertificate(block.Bytes)
+								Expect(err).NotTo(HaveOccurred())
+								_, err = cert.Verify(x509.VerifyOptions{
+									DNSName: mover.getAPIServiceDNS(),
+									Roots:   tlsConfig.RootCAs,
+								})
+								Expect(err).NotTo(HaveOccurred())
+							})
+
+							It("Should fail when the secret is missing the key", func() {
+								delete(certSecret.Data, corev1.TLSPrivateKeyKey)
+								Expect(k8sClient.Update(ctx, certSecret)).To(Succeed())
+								Expect(mover.ensureAPICertificate(ctx)).NotTo(Succeed())
+							})
+						})
+					})
 					Context("Privileged vs unprivileged mover", func() {
 						It("Should not have a PodSecurityContext by default", func() {
 							deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
@@ -1233,14 +4151,72 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							It("Should appear in the mover Job", func() {
 								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
 								Expect(err).NotTo(HaveOccurred())
-								Expect(deployment).NotTo(BeNil())
+								Expect(deployment).NotTo(BeNil())
+
+								psc := deployment.Spec.Template.Spec.SecurityContext
+								Expect(psc).NotTo(BeNil())
+								Expect(psc.RunAsUser).NotTo(BeNil())
+								Expect(*psc.RunAsUser).To(Equal(int64(7)))
+								Expect(psc.FSGroup).NotTo(BeNil())
+								Expect(*psc.FSGroup).To(Equal(int64(8)))
+							})
+						})
+
+						When("A restricted moverSecurityContext is provided", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.MoverSecurityContext = &corev1.PodSecurityContext{
+									RunAsNonRoot: pointer.Bool(true),
+									RunAsUser:    pointer.Int64(1000),
+									FSGroup:      pointer.Int64(1000),
+								}
+							})
+							It("Should run under the restricted Pod Security Standard", func() {
+								mover.privileged = false
+
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(deployment).NotTo(BeNil())
+
+								// the volumes are made writable to the user through the fsGroup
+								psc := deployment.Spec.Template.Spec.SecurityContext
+								Expect(psc).To(Equal(rs.Spec.Syncthing.MoverSecurityContext))
+
+								csc := deployment.Spec.Template.Spec.Containers[0].SecurityContext
+								Expect(csc.RunAsNonRoot).To(BeNil())
+								Expect(csc.RunAsUser).To(BeNil())
+								Expect(*csc.AllowPrivilegeEscalation).To(BeFalse())
+								Expect(*csc.Privileged).To(BeFalse())
+								Expect(csc.Capabilities.Drop).To(Equal([]corev1.Capability{"ALL"}))
+								Expect(csc.Capabilities.Add).To(BeEmpty())
+								Expect(csc.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+							})
+						})
+
+						When("sysctls are provided", func() {
+							BeforeEach(func() {
+								rs.Spec.Syncthing.MoverSecurityContext = &corev1.PodSecurityContext{
+									RunAsUser: pointer.Int64(7),
+								}
+								rs.Spec.Syncthing.Sysctls = []corev1.Sysctl{
+									{Name: "net.core.rmem_max", Value: "7500000"},
+									{Name: "net.ipv4.tcp_keepalive_time", Value: "600"},
+								}
+							})
+							It("Should appear on the pod security context", func() {
+								Expect(mover.validateSysctls()).To(Succeed())
+								deployment, err := mover.ensureDeployment(ctx, srcPVC, configPVC, sa, apiSecret)
+								Expect(err).NotTo(HaveOccurred())
 
 								psc := deployment.Spec.Template.Spec.SecurityContext
 								Expect(psc).NotTo(BeNil())
-								Expect(psc.RunAsUser).NotTo(BeNil())
+								Expect(psc.Sysctls).To(Equal(rs.Spec.Syncthing.Sysctls))
 								Expect(*psc.RunAsUser).To(Equal(int64(7)))
-								Expect(psc.FSGroup).NotTo(BeNil())
-								Expect(*psc.FSGroup).To(Equal(int64(8)))
+								// the security context from the spec is left untouched
+								Expect(rs.Spec.Syncthing.MoverSecurityContext.Sysctls).To(BeEmpty())
+							})
+							It("Should refuse sysctls which aren't namespaced", func() {
+								mover.sysctls = append(mover.sysctls, corev1.Sysctl{Name: "kernel.pid_max", Value: "65536"})
+								Expect(mover.validateSysctls()).To(MatchError(ContainSubstring("kernel.pid_max")))
 							})
 						})
 
@@ -1261,6 +4237,9 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 									Drop: []corev1.Capability{"ALL"},
 								}))
 								Expect(stContainer.SecurityContext.RunAsUser).To(BeNil())
+								Expect(stContainer.SecurityContext.SeccompProfile).To(Equal(&corev1.SeccompProfile{
+									Type: corev1.SeccompProfileTypeRuntimeDefault,
+								}))
 
 								foundPrivilegedMoverEnvVar := false
 								for _, envVar := range stContainer.Env {
@@ -1336,7 +4315,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							Expect(deployment).NotTo(BeNil())
 
 							// ensure the API service
-							apiService, err = mover.ensureAPIService(ctx, deployment)
+							apiService, err = mover.ensureAPIService(ctx, &deployment.Spec.Template)
 							Expect(err).NotTo(HaveOccurred())
 							Expect(apiService).NotTo(BeNil())
 
@@ -1352,7 +4331,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							Expect(deployment).NotTo(BeNil())
 
 							// ensure the API service
-							apiService, err = mover.ensureAPIService(ctx, deployment)
+							apiService, err = mover.ensureAPIService(ctx, &deployment.Spec.Template)
 							Expect(err).NotTo(HaveOccurred())
 							Expect(apiService).NotTo(BeNil())
 
@@ -1378,7 +4357,7 @@ var _ = Describe("When an RS specifies Syncthing", func() {
 							Expect(apiService.Spec.Ports[0].Port).To(Equal(apiPort.ContainerPort))
 
 							// API Service gets reused
-							newAPIService, err := mover.ensureAPIService(ctx, deployment)
+							newAPIService, err := mover.ensureAPIService(ctx, &deployment.Spec.Template)
 							Expect(err).NotTo(HaveOccurred())
 							Expect(newAPIService).NotTo(BeNil())
 							Expect(newAPIService.ObjectMeta.Name).To(Equal(apiService.ObjectMeta.Name))
@@ -1425,6 +4404,672 @@ var _ = Describe("Syncthing utils", func() {
 			syncthing.SystemStatus.MyID = myID.GoString()
 		})
 
+		When("folders are called to update", func() {
+			BeforeEach(func() {
+				syncthing.Configuration.Folders = append(syncthing.Configuration.Folders, config.FolderConfiguration{
+					ID:    string(sha256.New().Sum([]byte("festivus-files-1986"))),
+					Label: "festivus-files",
+				})
+			})
+
+			It("defaults ignoreDelete to false", func() {
+				syncthing.Configuration.Folders[0].IgnoreDelete = true
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].IgnoreDelete).To(BeFalse())
+			})
+
+			It("sets ignoreDelete and reports when it changes", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{IgnoreDelete: true}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].IgnoreDelete).To(BeTrue())
+
+				// no drift, nothing to update
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+			})
+
+			It("sets the marker name, defaulting to .stfolder", func() {
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].MarkerName).To(Equal(".stfolder"))
+
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{MarkerName: ".volsync-marker"}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].MarkerName).To(Equal(".volsync-marker"))
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+			})
+
+			It("sets the label without changing the folder ID", func() {
+				folderID := syncthing.Configuration.Folders[0].ID
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{Label: "Vandelay Industries"}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Label).To(Equal("Vandelay Industries"))
+				Expect(syncthing.Configuration.Folders[0].ID).To(Equal(folderID))
+
+				// the default label is restored once it's removed from the spec
+				Expect(updateSyncthingFolders(&volsyncv1alpha1.SyncthingFolderSpec{}, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Label).To(Equal(defaultFolderLabel))
+				Expect(syncthing.Configuration.Folders[0].ID).To(Equal(folderID))
+			})
+
+			It("sets the max concurrent writes, which serialize into the folder config", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{MaxConcurrentWrites: 1}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"maxConcurrentWrites":1`))
+
+				// drift is reverted, and Syncthing's default is restored once it's unset
+				syncthing.Configuration.Folders[0].MaxConcurrentWrites = 16
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].MaxConcurrentWrites).To(Equal(1))
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].MaxConcurrentWrites).To(Equal(2))
+			})
+
+			It("sets the mod time window, which serializes into the folder config", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{ModTimeWindowS: 2}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+				Expect(syncthing.Configuration.Folders[0].ModTimeWindow()).To(Equal(2 * time.Second))
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"modTimeWindowS":2`))
+
+				// the window is removed once it's unset
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].RawModTimeWindowS).To(BeZero())
+			})
+
+			It("sets the puller's max pending KiB, which serializes into the folder config", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{PullerMaxPendingKiB: 65536}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"pullerMaxPendingKiB":65536`))
+
+				// drift is reverted, and the choice is left to Syncthing once it's unset
+				syncthing.Configuration.Folders[0].PullerMaxPendingKiB = 1024
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].PullerMaxPendingKiB).To(Equal(65536))
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].PullerMaxPendingKiB).To(BeZero())
+			})
+
+			It("sets the filesystem type, which serializes into the folder config", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{FilesystemType: "fake"}
+				Expect(validateFolderSpec(folderSpec)).To(Succeed())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"filesystemType":"fake"`))
+
+				// the type is reverted to basic once it's unset
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].FilesystemType).To(Equal(fs.FilesystemTypeBasic))
+			})
+
+			It("rejects an unsupported filesystem type", func() {
+				Expect(validateFolderSpec(nil)).To(Succeed())
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{FilesystemType: "basic"})).To(Succeed())
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{FilesystemType: "encrypted"})).NotTo(Succeed())
+			})
+
+			It("sets the folder type, which serializes into the folder config", func() {
+				for _, folderType := range []string{"sendonly", "sendreceive", "receiveonly"} {
+					folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{Type: folderType}
+					Expect(validateFolderSpec(folderSpec)).To(Succeed())
+					updateSyncthingFolders(folderSpec, &syncthing)
+					Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+					folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(folderJSON)).To(ContainSubstring(`"type":"` + folderType + `"`))
+				}
+
+				// the type is reverted to sendreceive once it's unset
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Type).To(Equal(config.FolderTypeSendReceive))
+			})
+
+			It("rejects an unsupported folder type", func() {
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{Type: "receiveonly"})).To(Succeed())
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{Type: "receiveencrypted"})).NotTo(Succeed())
+			})
+
+			It("sets the versioning, which serializes into the folder config", func() {
+				// no versions are kept by default
+				updateSyncthingFolders(nil, &syncthing)
+				Expect(syncthing.Configuration.Folders[0].Versioning.Type).To(BeEmpty())
+
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{
+					Versioning: &volsyncv1alpha1.SyncthingVersioningSpec{
+						Type:   "trashcan",
+						Params: map[string]string{"cleanoutDays": "30"},
+					},
+				}
+				Expect(validateFolderSpec(folderSpec)).To(Succeed())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+				versioningJSON, err := json.Marshal(syncthing.Configuration.Folders[0].Versioning)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(versioningJSON)).To(ContainSubstring(`"type":"trashcan","params":{"cleanoutDays":"30"}`))
+
+				folderSpec.Versioning = &volsyncv1alpha1.SyncthingVersioningSpec{
+					Type:   "staggered",
+					Params: map[string]string{"maxAge": "864000"},
+				}
+				Expect(validateFolderSpec(folderSpec)).To(Succeed())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				versioningJSON, err = json.Marshal(syncthing.Configuration.Folders[0].Versioning)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(versioningJSON)).To(ContainSubstring(`"type":"staggered","params":{"maxAge":"864000"}`))
+
+				// drift in the params is reverted
+				syncthing.Configuration.Folders[0].Versioning.Params["maxAge"] = "0"
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Versioning.Params).To(
+					Equal(map[string]string{"maxAge": "864000"}))
+
+				// and versioning is disabled once it's unset
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Versioning.Type).To(BeEmpty())
+				Expect(syncthing.Configuration.Folders[0].Versioning.Params).To(BeEmpty())
+			})
+
+			It("sets the rescan interval, which serializes into the folder config", func() {
+				syncthing.Configuration.Folders[0].RescanIntervalS = 3600
+				// Syncthing's setting is left untouched by default
+				updateSyncthingFolders(nil, &syncthing)
+				Expect(syncthing.Configuration.Folders[0].RescanIntervalS).To(Equal(3600))
+
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{RescanIntervalS: pointer.Int32(86400)}
+				Expect(validateFolderSpec(folderSpec)).To(Succeed())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"rescanIntervalS":86400`))
+
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{
+					RescanIntervalS: pointer.Int32(-1),
+				})).NotTo(Succeed())
+			})
+
+			It("only manages the filesystem watcher when it's set", func() {
+				// e.g. the watcher was disabled through the web UI
+				syncthing.Configuration.Folders[0].FSWatcherEnabled = false
+				updateSyncthingFolders(nil, &syncthing)
+				Expect(syncthing.Configuration.Folders[0].FSWatcherEnabled).To(BeFalse())
+				updateSyncthingFolders(&volsyncv1alpha1.SyncthingFolderSpec{}, &syncthing)
+				Expect(syncthing.Configuration.Folders[0].FSWatcherEnabled).To(BeFalse())
+				syncthing.Configuration.Folders[0].FSWatcherEnabled = true
+
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{FSWatcherEnabled: pointer.Bool(false)}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"fsWatcherEnabled":false`))
+
+				folderSpec.FSWatcherEnabled = pointer.Bool(true)
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				folderJSON, err = json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"fsWatcherEnabled":true`))
+			})
+
+			It("rejects an unsupported versioning type", func() {
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{
+					Versioning: &volsyncv1alpha1.SyncthingVersioningSpec{Type: "simple"},
+				})).To(Succeed())
+				Expect(validateFolderSpec(&volsyncv1alpha1.SyncthingFolderSpec{
+					Versioning: &volsyncv1alpha1.SyncthingVersioningSpec{Type: "external"},
+				})).NotTo(Succeed())
+			})
+
+			It("sets the block pull order, which serializes into the folder config", func() {
+				for order, expected := range map[string]config.BlockPullOrder{
+					"inOrder":  config.BlockPullOrderInOrder,
+					"random":   config.BlockPullOrderRandom,
+					"standard": config.BlockPullOrderStandard,
+				} {
+					folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{BlockPullOrder: order}
+					updateSyncthingFolders(folderSpec, &syncthing)
+					Expect(syncthing.Configuration.Folders[0].BlockPullOrder).To(Equal(expected))
+					Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+					folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(folderJSON)).To(ContainSubstring(`"blockPullOrder":"` + order + `"`))
+				}
+
+				// the standard order is restored once it's unset
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{BlockPullOrder: "inOrder"}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].BlockPullOrder).To(Equal(config.BlockPullOrderStandard))
+			})
+
+			It("sets the xattr options, which serialize into the folder config", func() {
+				folderSpec := &volsyncv1alpha1.SyncthingFolderSpec{
+					SendXattrs: true,
+					SyncXattrs: true,
+					XattrFilter: []volsyncv1alpha1.SyncthingXattrFilterEntry{
+						{Match: "security.selinux", Permit: true},
+						{Match: "*"},
+					},
+				}
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeFalse())
+
+				folderJSON, err := json.Marshal(syncthing.Configuration.Folders[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(folderJSON)).To(ContainSubstring(`"sendXattrs":true`))
+				Expect(string(folderJSON)).To(ContainSubstring(`"syncXattrs":true`))
+				Expect(string(folderJSON)).To(ContainSubstring(
+					`"entries":[{"match":"security.selinux","permit":true},{"match":"*","permit":false}]`))
+
+				// drift is reverted, and xattrs are off by default
+				syncthing.Configuration.Folders[0].XattrFilter.Entries[1].Permit = true
+				Expect(updateSyncthingFolders(folderSpec, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].XattrFilter.Entries[1].Permit).To(BeFalse())
+				Expect(updateSyncthingFolders(nil, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].SendXattrs).To(BeFalse())
+				Expect(syncthing.Configuration.Folders[0].SyncXattrs).To(BeFalse())
+				Expect(syncthing.Configuration.Folders[0].XattrFilter.Entries).To(BeEmpty())
+			})
+		})
+
+		When("options are called to update", func() {
+			It("leaves them untouched when none are provided", func() {
+				syncthing.Configuration.Options.ProgressUpdateIntervalS = 5
+				changed, err := updateSyncthingOptions(nil, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+				Expect(syncthing.Configuration.Options.ProgressUpdateIntervalS).To(Equal(5))
+			})
+
+			It("sets progressUpdateIntervalS", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{ProgressUpdateIntervalS: pointer.Int32(20)}
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.ProgressUpdateIntervalS).To(Equal(20))
+			})
+
+			It("reverts drift in the LAN announcement options", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{
+					AnnounceLANAddresses: pointer.Bool(true),
+					LocalAnnounceEnabled: pointer.Bool(false),
+				}
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.AnnounceLANAddresses).To(BeTrue())
+				Expect(syncthing.Configuration.Options.LocalAnnEnabled).To(BeFalse())
+
+				// nothing changes once they're in sync
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+
+				// drift is reverted
+				syncthing.Configuration.Options.LocalAnnEnabled = true
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.LocalAnnEnabled).To(BeFalse())
+			})
+
+			It("sets tempIndexMinBlocks, which serializes into the options", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{TempIndexMinBlocks: pointer.Int32(0)}
+				syncthing.Configuration.Options.TempIndexMinBlocks = 10
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+
+				optionsJSON, err := json.Marshal(syncthing.Configuration.Options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(optionsJSON)).To(ContainSubstring(`"tempIndexMinBlocks":0`))
+
+				// drift is reverted
+				syncthing.Configuration.Options.TempIndexMinBlocks = 100
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.TempIndexMinBlocks).To(BeZero())
+			})
+
+			It("rejects a negative tempIndexMinBlocks", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{TempIndexMinBlocks: pointer.Int32(-1)}
+				_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("sets keepTemporariesH, which serializes into the options", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{KeepTemporariesH: pointer.Int32(72)}
+				syncthing.Configuration.Options.KeepTemporariesH = 24
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+
+				optionsJSON, err := json.Marshal(syncthing.Configuration.Options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(optionsJSON)).To(ContainSubstring(`"keepTemporariesH":72`))
+
+				// nothing changes once it's applied
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+			})
+
+			It("rejects a negative keepTemporariesH", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{KeepTemporariesH: pointer.Int32(-1)}
+				_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("sets the global rate limits, which serialize into the options", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{
+					MaxSendKbps: pointer.Int32(1024),
+					MaxRecvKbps: pointer.Int32(4096),
+				}
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+
+				optionsJSON, err := json.Marshal(syncthing.Configuration.Options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(optionsJSON)).To(ContainSubstring(`"maxSendKbps":1024`))
+				Expect(string(optionsJSON)).To(ContainSubstring(`"maxRecvKbps":4096`))
+
+				// nothing changes once they're applied
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+
+				// a limit set in the GUI is left alone once it's unset
+				syncthing.Configuration.Options.MaxSendKbps = 512
+				changed, err = updateSyncthingOptions(&volsyncv1alpha1.SyncthingOptionsSpec{
+					MaxRecvKbps: pointer.Int32(4096),
+				}, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+				Expect(syncthing.Configuration.Options.MaxSendKbps).To(Equal(512))
+				changed, err = updateSyncthingOptions(nil, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+				Expect(syncthing.Configuration.Options.MaxRecvKbps).To(Equal(4096))
+			})
+
+			It("rejects negative rate limits", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{MaxRecvKbps: pointer.Int32(-1)}
+				_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("maxRecvKbps"))
+			})
+
+			It("pins the relays to the allowlist, in place of the public relay pool", func() {
+				relay := "relay://relay.example.com:22067/?id=" + device1.GoString()
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{RelayServers: []string{relay}}
+				syncthing.Configuration.Options.RelaysEnabled = false
+				syncthing.Configuration.Options.RawListenAddresses = []string{
+					"default", "relay://unapproved.example.com:22067",
+				}
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.RelaysEnabled).To(BeTrue())
+				Expect(syncthing.Configuration.Options.RawListenAddresses).To(Equal([]string{
+					"tcp://0.0.0.0:22000", "quic://0.0.0.0:22000", relay,
+				}))
+				Expect(syncthing.Configuration.Options.ListenAddresses()).NotTo(
+					ContainElement(ContainSubstring("relays.syncthing.net")))
+
+				// nothing changes once the relays are pinned
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+
+				// the default listeners and relay pool are restored once the relays are cleared
+				syncthing.Configuration.Options.RawListenAddresses = append(
+					syncthing.Configuration.Options.RawListenAddresses, "tcp://0.0.0.0:22001")
+				changed, err = updateSyncthingOptions(&volsyncv1alpha1.SyncthingOptionsSpec{}, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.RelaysEnabled).To(BeTrue())
+				Expect(syncthing.Configuration.Options.RawListenAddresses).To(Equal([]string{
+					"default", "tcp://0.0.0.0:22001",
+				}))
+				changed, err = updateSyncthingOptions(nil, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+			})
+
+			It("releases the relays when the options are removed", func() {
+				relay := "relay://relay.example.com:22067/?id=" + device1.GoString()
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{RelayServers: []string{relay}}
+				syncthing.Configuration.Options.RawListenAddresses = []string{"default"}
+				_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				changed, err := updateSyncthingOptions(nil, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.RawListenAddresses).To(Equal([]string{"default"}))
+			})
+
+			It("leaves listen addresses which weren't pinned alone", func() {
+				syncthing.Configuration.Options.RelaysEnabled = false
+				syncthing.Configuration.Options.RawListenAddresses = []string{"tcp://0.0.0.0:22000"}
+				changed, err := updateSyncthingOptions(nil, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+				Expect(syncthing.Configuration.Options.RelaysEnabled).To(BeFalse())
+			})
+
+			It("rejects relays which Syncthing can't connect through", func() {
+				for _, relay := range []string{
+					"tcp://relay.example.com:22067",
+					"relay://relay.example.com",
+					"relay://:22067",
+					"relay://relay.example.com:22067/?id=not-a-device-id",
+				} {
+					optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{RelayServers: []string{relay}}
+					_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+					Expect(err).To(HaveOccurred(), relay)
+				}
+			})
+
+			It("pins global discovery to the given servers, in place of the public ones", func() {
+				server := "https://discovery.example.com:8443/?id=" + device1.GoString()
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{DiscoveryServers: []string{server}}
+				syncthing.Configuration.Options.GlobalAnnEnabled = false
+				syncthing.Configuration.Options.RawGlobalAnnServers = []string{"default"}
+				changed, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeTrue())
+				Expect(syncthing.Configuration.Options.GlobalAnnEnabled).To(BeTrue())
+				Expect(syncthing.Configuration.Options.GlobalDiscoveryServers()).To(Equal([]string{server}))
+
+				// nothing changes once the servers are pinned
+				changed, err = updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(changed).To(BeFalse())
+			})
+
+			It("rejects discovery servers which aren't well-formed", func() {
+				for _, server := range []string{
+					"http://discovery.example.com",
+					"https://",
+					"https://discovery.example.com/?id=not-a-device-id",
+					"://discovery.example.com",
+				} {
+					optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{DiscoveryServers: []string{server}}
+					_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+					Expect(err).To(HaveOccurred(), server)
+				}
+			})
+
+			It("rejects a progressUpdateIntervalS that isn't positive", func() {
+				optionsSpec := &volsyncv1alpha1.SyncthingOptionsSpec{ProgressUpdateIntervalS: pointer.Int32(0)}
+				_, err := updateSyncthingOptions(optionsSpec, &syncthing)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("folder encryption passwords are applied", func() {
+			BeforeEach(func() {
+				syncthing.Configuration.Devices = []config.DeviceConfiguration{
+					{DeviceID: device1}, {DeviceID: device2},
+				}
+				syncthing.Configuration.Folders = []config.FolderConfiguration{
+					{ID: "festivus-files", Path: "/data"},
+				}
+				syncthing.ShareFoldersWithDevices(syncthing.Configuration.Devices)
+			})
+
+			It("only sets the passwords of the devices it has one for", func() {
+				syncthing.Configuration.Folders[0].Devices[0].EncryptionPassword = "set-in-the-gui"
+				passwords := map[string]string{device2.GoString(): "serenity-now"}
+				Expect(updateFolderEncryptionPasswords(passwords, &syncthing)).To(BeTrue())
+				Expect(syncthing.Configuration.Folders[0].Devices[0].EncryptionPassword).To(Equal("set-in-the-gui"))
+				Expect(syncthing.Configuration.Folders[0].Devices[1].EncryptionPassword).To(Equal("serenity-now"))
+
+				// nothing changes once they're applied, or without any passwords
+				Expect(updateFolderEncryptionPasswords(passwords, &syncthing)).To(BeFalse())
+				Expect(updateFolderEncryptionPasswords(nil, &syncthing)).To(BeFalse())
+				Expect(syncthing.Configuration.Folders[0].Devices[1].EncryptionPassword).To(Equal("serenity-now"))
+			})
+
+			It("keeps the passwords when the folders are shared again", func() {
+				passwords := map[string]string{device2.GoString(): "serenity-now"}
+				Expect(updateFolderEncryptionPasswords(passwords, &syncthing)).To(BeTrue())
+				syncthing.ShareFoldersWithDevices(syncthing.Configuration.Devices)
+				Expect(syncthing.Configuration.Folders[0].Devices[1].EncryptionPassword).To(Equal("serenity-now"))
+				Expect(updateFolderEncryptionPasswords(passwords, &syncthing)).To(BeFalse())
+			})
+		})
+
+		When("folder states are normalized", func() {
+			It("maps Syncthing's states onto the reported states", func() {
+				expectedStates := map[string]string{
+					"idle":           volsyncv1alpha1.SyncthingFolderStateIdle,
+					"scanning":       volsyncv1alpha1.SyncthingFolderStateScanning,
+					"scan-waiting":   volsyncv1alpha1.SyncthingFolderStateScanning,
+					"syncing":        volsyncv1alpha1.SyncthingFolderStateSyncing,
+					"sync-preparing": volsyncv1alpha1.SyncthingFolderStateSyncing,
+					"sync-waiting":   volsyncv1alpha1.SyncthingFolderStateSyncing,
+					"cleaning":       volsyncv1alpha1.SyncthingFolderStateSyncing,
+					"error":          volsyncv1alpha1.SyncthingFolderStateError,
+					"":               volsyncv1alpha1.SyncthingFolderStateUnknown,
+					"yada-yada-yada": volsyncv1alpha1.SyncthingFolderStateUnknown,
+				}
+				for state, expected := range expectedStates {
+					Expect(normalizeFolderState(state)).To(Equal(expected), "state: %q", state)
+				}
+			})
+		})
+
+		When("the config volume is sized automatically", func() {
+			It("scales the capacity with the number of folders", func() {
+				base := resource.MustParse("1Gi")
+				expectedCapacities := map[int]string{
+					0: "1Gi",
+					1: "1Gi",
+					2: "1536Mi",
+					5: "3Gi",
+				}
+				for folders, expected := range expectedCapacities {
+					capacity := scaleConfigCapacity(base, folders)
+					Expect(capacity.Cmp(resource.MustParse(expected))).To(BeZero(), "folders: %d", folders)
+				}
+				// the base capacity isn't modified
+				Expect(base.Cmp(resource.MustParse("1Gi"))).To(BeZero())
+			})
+		})
+
+		When("the image pull policy is derived from the image", func() {
+			It("only pulls images pinned by digest when they're not present", func() {
+				expectedPolicies := map[string]corev1.PullPolicy{
+					"quay.io/backube/volsync:latest": corev1.PullAlways,
+					"quay.io/backube/volsync:v0.8.0": corev1.PullAlways,
+					"quay.io/backube/volsync":        corev1.PullAlways,
+					"quay.io/backube/volsync@sha256:" +
+						"4a1c4b21597c1b4415bdbecb28a3296c6b5e23ca4f9feeb599860a1dac6a0108": corev1.PullIfNotPresent,
+					"localhost:5000/volsync:latest@sha256:" +
+						"4a1c4b21597c1b4415bdbecb28a3296c6b5e23ca4f9feeb599860a1dac6a0108": corev1.PullIfNotPresent,
+				}
+				for image, expected := range expectedPolicies {
+					Expect(imagePullPolicyFor(image)).To(Equal(expected), "image: %q", image)
+				}
+			})
+		})
+
+		When("folder ETAs are estimated", func() {
+			It("computes the ETA from the recent transfer rate and the bytes needed", func() {
+				previousPeers := []volsyncv1alpha1.SyncthingPeerStatus{
+					// the lifetime average of peer-1 is much higher, after an initial burst
+					{ID: "peer-1", Connected: true, InBytesTotal: 50 << 20},
+					{ID: "peer-2", Connected: true, InBytesTotal: 1000},
+					{ID: "peer-3", Connected: true, InBytesTotal: 1 << 20},
+				}
+				peers := []volsyncv1alpha1.SyncthingPeerStatus{
+					// 1000 B/s over the last 100s
+					{ID: "peer-1", Connected: true, InBytesTotal: 50<<20 + 100000},
+					// disconnected peers don't contribute to the rate
+					{ID: "peer-2", Connected: false, InBytesTotal: 500000},
+					// nor do peers whose connection was reset
+					{ID: "peer-3", Connected: true, InBytesTotal: 4096},
+					// nor peers which weren't connected at the previous sample
+					{ID: "peer-4", Connected: true, InBytesTotal: 500000},
+				}
+				rate := getTransferRate(previousPeers, peers, 100*time.Second)
+				Expect(rate).To(BeNumerically("~", 1000, 1))
+
+				eta := estimateFolderETA(volsyncv1alpha1.SyncthingFolderStateSyncing, 90000, rate)
+				Expect(time.ParseDuration(eta)).To(BeNumerically("~", 90*time.Second, time.Second))
+
+				// a stall is reflected right away
+				Expect(getTransferRate(peers, peers, 100*time.Second)).To(BeZero())
+			})
+
+			It("doesn't report an ETA for folders that aren't syncing", func() {
+				Expect(estimateFolderETA(volsyncv1alpha1.SyncthingFolderStateIdle, 0, 1000)).To(BeEmpty())
+				Expect(estimateFolderETA(volsyncv1alpha1.SyncthingFolderStateScanning, 90000, 1000)).To(BeEmpty())
+			})
+
+			It("reports an unknown ETA until a transfer rate is observed", func() {
+				peers := []volsyncv1alpha1.SyncthingPeerStatus{{ID: "peer-1", Connected: true, InBytesTotal: 100000}}
+				Expect(getTransferRate(nil, peers, 0)).To(BeZero())
+				Expect(estimateFolderETA(volsyncv1alpha1.SyncthingFolderStateSyncing, 90000, 0)).To(
+					Equal(volsyncv1alpha1.SyncthingFolderETAUnknown))
+			})
+		})
+
+		When("conflicts are searched for", func() {
+			It("counts all of them but only lists a bounded number", func() {
+				entries := []api.FileEntry{}
+				for i := 0; i < maxConflictsReported+5; i++ {
+					entries = append(entries, api.FileEntry{
+						Name: "file-" + strconv.Itoa(i) + ".sync-conflict-20230101-120000-AIR6LPZ",
+					})
+				}
+				conflicts, conflictingFiles := findConflicts("", []api.FileEntry{
+					{Name: "nested", Children: entries},
+				})
+				Expect(conflicts).To(Equal(maxConflictsReported + 5))
+				Expect(conflictingFiles).To(HaveLen(maxConflictsReported))
+				Expect(conflictingFiles[0]).To(HavePrefix("nested/"))
+			})
+		})
+
 		When("devices are called to update", func() {
 			BeforeEach(func() {
 				// create a folder
@@ -1480,6 +5125,52 @@ var _ = Describe("Syncthing utils", func() {
 				}
 			})
 
+			It("drops a peer and its folder shares once it's removed from the peerList", func() {
+				peerList := []volsyncv1alpha1.SyncthingPeer{
+					{
+						ID:      device1.GoString(),
+						Address: "tcp://127.0.0.1:22000",
+					},
+					{
+						ID:      device2.GoString(),
+						Address: "tcp://192.168.1.1:22000",
+					},
+				}
+				Expect(updateSyncthingDevices(peerList, &syncthing)).To(Succeed())
+				Expect(syncthing.Configuration.Devices).To(HaveLen(2))
+
+				// removing the second peer is picked up as a change
+				peerList = peerList[:1]
+				Expect(syncthingNeedsReconfigure(peerList, &syncthing)).To(BeTrue())
+				Expect(updateSyncthingDevices(peerList, &syncthing)).To(Succeed())
+				Expect(syncthing.Configuration.Devices).To(HaveLen(1))
+				Expect(syncthing.Configuration.Devices[0].DeviceID).To(Equal(device1))
+				for _, folder := range syncthing.Configuration.Folders {
+					Expect(folder.Devices).To(HaveLen(1))
+					Expect(folder.Devices[0].DeviceID).To(Equal(device1))
+				}
+				Expect(syncthingNeedsReconfigure(peerList, &syncthing)).To(BeFalse())
+			})
+
+			It("marks the peers flagged as introducers", func() {
+				peerList := []volsyncv1alpha1.SyncthingPeer{
+					{
+						ID:         device1.GoString(),
+						Address:    "tcp://127.0.0.1:22000",
+						Introducer: true,
+					},
+					{
+						ID:      device2.GoString(),
+						Address: "tcp://192.168.1.1:22000",
+					},
+				}
+				Expect(updateSyncthingDevices(peerList, &syncthing)).To(Succeed())
+				Expect(syncthing.Configuration.Devices).To(HaveLen(2))
+				for _, device := range syncthing.Configuration.Devices {
+					Expect(device.Introducer).To(Equal(device.DeviceID == device1))
+				}
+			})
+
 			When("syncthing lists itself within the devices entries", func() {
 				BeforeEach(func() {
 					// make sure that the syncthing is listed in the connections
@@ -1632,6 +5323,48 @@ var _ = Describe("Syncthing utils", func() {
 					Expect(found).To(Equal(len(syncthing.Configuration.Folders[0].Devices)))
 				})
 
+				It("reconfigures when only a device's options change", func() {
+					peerList := []volsyncv1alpha1.SyncthingPeer{
+						{
+							ID:          device1.GoString(),
+							Address:     "tcp://[::1]:22000",
+							Compression: "always",
+						},
+						{
+							ID:      device2.GoString(),
+							Address: "tcp://[::2]:22000",
+						},
+						{
+							ID:      device3.GoString(),
+							Address: "tcp://[::3]:22000",
+						},
+					}
+					Expect(syncthingNeedsReconfigure(peerList, &syncthing)).To(BeTrue())
+
+					// the new compression is applied
+					Expect(updateSyncthingDevices(peerList, &syncthing)).To(Succeed())
+					for _, device := range syncthing.Configuration.Devices {
+						if device.DeviceID == device1 {
+							Expect(device.Compression).To(Equal(protocol.CompressionAlways))
+						}
+					}
+					Expect(syncthingNeedsReconfigure(peerList, &syncthing)).To(BeFalse())
+
+					// each of the other options is compared as well
+					changedPeers := map[string]func(*volsyncv1alpha1.SyncthingPeer){
+						"address":     func(p *volsyncv1alpha1.SyncthingPeer) { p.Address = "tcp://[::4]:22000" },
+						"introducer":  func(p *volsyncv1alpha1.SyncthingPeer) { p.Introducer = true },
+						"paused":      func(p *volsyncv1alpha1.SyncthingPeer) { p.Paused = true },
+						"maxSendKbps": func(p *volsyncv1alpha1.SyncthingPeer) { p.MaxSendKbps = 100 },
+						"maxRecvKbps": func(p *volsyncv1alpha1.SyncthingPeer) { p.MaxRecvKbps = 100 },
+					}
+					for option, change := range changedPeers {
+						changedPeerList := append([]volsyncv1alpha1.SyncthingPeer{}, peerList...)
+						change(&changedPeerList[1])
+						Expect(syncthingNeedsReconfigure(changedPeerList, &syncthing)).To(BeTrue(), "option: %s", option)
+					}
+				})
+
 				It("only needs reconfigure when the list differs but ignores the self syncthing device", func() {
 					// test with an empty list
 					peerList := []volsyncv1alpha1.SyncthingPeer{}
@@ -1787,3 +5520,96 @@ var _ = Describe("Syncthing utils", func() {
 		})
 	})
 })
+
+// racingClient Simulates another reconcile creating an object in between VolSync's Get and Create,
+// by creating the object and then reporting that it already exists.
+type racingClient struct {
+	client.Client
+}
+
+func (c *racingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return kerrors.NewAlreadyExists(schema.GroupResource{}, obj.GetName())
+}
+
+// forgetfulConnection Simulates a Syncthing instance which can't persist its config, by accepting
+// every config published to it without applying it.
+type forgetfulConnection struct {
+	api.SyncthingConnection
+	published int
+}
+
+func (c *forgetfulConnection) PublishConfig(context.Context, config.Configuration) error {
+	c.published++
+	return nil
+}
+
+// failingConnection Simulates a Syncthing instance whose API goes away, by failing every config
+// published to it.
+type failingConnection struct {
+	api.SyncthingConnection
+}
+
+func (c *failingConnection) PublishConfig(context.Context, config.Configuration) error {
+	return fmt.Errorf("connection refused")
+}
+
+// unbrowsableConnection Simulates a Syncthing instance whose folders can't be browsed.
+type unbrowsableConnection struct {
+	api.SyncthingConnection
+}
+
+func (c *unbrowsableConnection) FetchFolderEntries(context.Context, string, int) ([]api.FileEntry, error) {
+	return nil, fmt.Errorf("unexpected HTTP status returned: 500 Internal Server Error")
+}
+
+// failingFolderConnection Simulates a Syncthing instance which fails to report the status of a folder.
+type failingFolderConnection struct {
+	api.SyncthingConnection
+	folderID string
+}
+
+func (c *failingFolderConnection) FetchFolderStatus(ctx context.Context, folderID string) (*api.FolderStatus, error) {
+	if folderID == c.folderID {
+		return nil, fmt.Errorf("unexpected HTTP status returned: 500 Internal Server Error")
+	}
+	return c.SyncthingConnection.FetchFolderStatus(ctx, folderID)
+}
+
+// fakeDialer Simulates dialing peers, only succeeding for the reachable addresses. Dialing the
+// hanging addresses blocks until the context is done, as a peer dropping the packets would.
+type fakeDialer struct {
+	mu        sync.Mutex
+	reachable map[string]bool
+	hanging   map[string]bool
+	dialed    []string
+}
+
+func (d *fakeDialer) DialContext(ctx context.Context, _ string, address string) (net.Conn, error) {
+	d.mu.Lock()
+	d.dialed = append(d.dialed, address)
+	reachable, hanging := d.reachable[address], d.hanging[address]
+	d.mu.Unlock()
+	if hanging {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if !reachable {
+		return nil, fmt.Errorf("dial tcp %s: connection refused", address)
+	}
+	conn, peerConn := net.Pipe()
+	_ = peerConn.Close()
+	return conn, nil
+}
+
+// stuckClient Simulates a step which never completes, by blocking every Get until its context is done.
+type stuckClient struct {
+	client.Client
+}
+
+func (c *stuckClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The VolSync authors.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package syncthing
+
+import (
+	"fmt"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+	corev1 "k8s.io/api/core/v1"
+	errorsutil "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/backube/volsync/api/v1alpha1"
+	"github.com/backube/volsync/controllers/mover/syncthing/api"
+)
+
+// ValidateSpec Computes the Syncthing config described by the given spec, starting from Syncthing's
+// defaults, without contacting Syncthing or the cluster. This allows a spec to be checked ahead of time,
+// e.g. in CI. All of the problems found with the spec are returned together, in which case no config
+// is returned.
+func ValidateSpec(spec *v1alpha1.ReplicationSourceSyncthingSpec) (*config.Configuration, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("the Syncthing spec cannot be nil")
+	}
+
+	errs := validatePeerSpec(spec.Peers, spec.MaxPeers)
+	errs = append(errs, validateMoverSpec(spec)...)
+	if err := validateFolderSpec(spec.Folder); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateOptionsSpec(spec.Options); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errorsutil.NewAggregate(errs)
+	}
+
+	syncthing := &api.Syncthing{Configuration: config.New(protocol.EmptyDeviceID)}
+	if spec.ManageFolders == nil || *spec.ManageFolders {
+		ensureManagedFolder(syncthing)
+		updateSyncthingFolders(spec.Folder, syncthing)
+	}
+	if err := updateSyncthingDevices(spec.Peers, syncthing); err != nil {
+		return nil, err
+	}
+	if _, err := updateSyncthingOptions(spec.Options, syncthing); err != nil {
+		return nil, err
+	}
+	return &syncthing.Configuration, nil
+}
+
+// validatePeerSpec Returns the errors found with each of the given peers, and with the list as a whole.
+func validatePeerSpec(peers []v1alpha1.SyncthingPeer, maxPeers *int32) []error {
+	errs := []error{}
+	seen := map[string]bool{}
+	for i, peer := range peers {
+		if _, err := peerToDevice(peer); err != nil {
+			errs = append(errs, fmt.Errorf("peers[%d]: %w", i, err))
+		}
+		if err := validateSyncthingAddress(peer.Address); err != nil {
+			errs = append(errs, fmt.Errorf("peers[%d]: %w", i, err))
+		}
+		if seen[peer.ID] {
+			errs = append(errs, fmt.Errorf("peers[%d]: duplicate peer found in peer list: %s", i, peer.ID))
+		}
+		seen[peer.ID] = true
+	}
+	if maxPeers != nil && len(peers) > int(*maxPeers) {
+		errs = append(errs, fmt.Errorf("the peer list contains %d peers, exceeding the maximum of %d",
+			len(peers), *maxPeers))
+	}
+	return errs
+}
+
+// validateMoverSpec Returns the errors found with the options of the mover's resources.
+func validateMoverSpec(spec *v1alpha1.ReplicationSourceSyncthingSpec) []error {
+	errs := []error{}
+	if spec.ServiceType != nil {
+		switch *spec.ServiceType {
+		case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		default:
+			errs = append(errs, fmt.Errorf("unsupported serviceType %q", *spec.ServiceType))
+		}
+	}
+	if spec.WorkloadType != nil {
+		switch *spec.WorkloadType {
+		case v1alpha1.SyncthingWorkloadDeployment, v1alpha1.SyncthingWorkloadStatefulSet:
+		default:
+			errs = append(errs, fmt.Errorf("unsupported workloadType %q", *spec.WorkloadType))
+		}
+	}
+	if spec.AdvertisedAddress != nil {
+		if err := validateSyncthingAddress(*spec.AdvertisedAddress); err != nil {
+			errs = append(errs, fmt.Errorf("advertisedAddress: %w", err))
+		}
+	}
+	for _, sysctl := range spec.Sysctls {
+		if !isNamespacedSysctl(sysctl.Name) {
+			errs = append(errs, fmt.Errorf("sysctl %s is not namespaced and can't be set on the mover", sysctl.Name))
+		}
+	}
+	if spec.APIKeySecretKey != nil {
+		if err := validateAPIKeySecretKey(*spec.APIKeySecretKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := validateSyncInterval(spec.SyncInterval); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateStatsSnapshots(spec.StatsSnapshots); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateIndexSnapshots(spec.IndexSnapshots); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateAPIExposure(spec.ExposeAPI, spec.PodLocalAPI); err != nil {
+		errs = append(errs, err)
+	}
+	// whether the mover is privileged depends on its namespace, so only the image reference is checked
+	if err := validateMoverImage(spec.MoverImage, false); err != nil {
+		errs = append(errs, err)
+	}
+
+	configVolumeName := defaultConfigVolumeName
+	if spec.ConfigVolumeName != nil {
+		configVolumeName = *spec.ConfigVolumeName
+	}
+	dataVolumeName := defaultDataVolumeName
+	if spec.DataVolumeName != nil {
+		dataVolumeName = *spec.DataVolumeName
+	}
+	if err := validateVolumeNames(configVolumeName, dataVolumeName, certVolumeName); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -10,6 +10,7 @@ import (
 	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -87,6 +88,17 @@ var _ = Describe("ReplicationSource", func() {
 		})
 	})
 
+	Context("when the CR is deleted", func() {
+		It("its metrics are removed", func() {
+			foldersInError.WithLabelValues(rs.Name, rs.Namespace, "source", "syncthing").Set(2)
+			series := testutil.CollectAndCount(foldersInError)
+			Expect(k8sClient.Delete(ctx, rs)).To(Succeed())
+			Eventually(func() int {
+				return testutil.CollectAndCount(foldersInError)
+			}, duration, interval).Should(Equal(series - 1))
+		})
+	})
+
 	Context("when no replication method is specified", func() {
 		It("the CR is reports an error in the status", func() {
 			Eventually(func() *volsyncv1alpha1.ReplicationSourceStatus {
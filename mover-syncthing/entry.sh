@@ -25,6 +25,9 @@ log_msg "STARTING CONTAINER"
 log_msg "VolSync Syncthing container version: ${version:-unknown}"
 log_msg "${@}"
 
+# the address Syncthing's GUI & API listen on, which the health check also targets
+export STGUIADDRESS="${STGUIADDRESS:-0.0.0.0:8384}"
+
 # variables we can't proceed without
 required_vars=(
   SYNCTHING_DATA_DIR
@@ -132,6 +135,42 @@ preflight_check() {
   ensure_https_certificates
 }
 
+#####################################################
+# Waits until the Syncthing API reports that it is
+# healthy, giving up once the timeout has elapsed.
+# Globals:
+#   STGUIADDRESS
+#   SYNCTHING_HEALTH_TIMEOUT
+#   SYNCTHING_HEALTH_PATH
+# Arguments:
+#   None
+# Returns:
+#   None
+#####################################################
+wait_until_healthy() {
+  local timeout="${SYNCTHING_HEALTH_TIMEOUT:-60}"
+  local path="${SYNCTHING_HEALTH_PATH:-/rest/noauth/health}"
+  local deadline=$((SECONDS + timeout))
+
+  # reach the API where Syncthing was told to listen, through the loopback when it listens on all interfaces
+  local host="${STGUIADDRESS%:*}"
+  local port="${STGUIADDRESS##*:}"
+  if [[ -z "${host}" || "${host}" == "0.0.0.0" || "${host}" == "[::]" ]]; then
+    host="127.0.0.1"
+  fi
+
+  log_msg "Waiting up to ${timeout}s for Syncthing to become healthy"
+  until curl -sfk "https://${host}:${port}${path}" > /dev/null; do
+    if (( SECONDS >= deadline )); then
+      # don't fail the container, the controller will retry on its own
+      log_msg "Syncthing is not healthy after ${timeout}s, continuing"
+      return 0
+    fi
+    sleep 1
+  done
+  log_msg "Syncthing is healthy"
+}
+
 for op in "$@"; do
   case $op in
     "run")
@@ -146,6 +185,9 @@ for op in "$@"; do
       # launch syncthing
       exec syncthing -home "${SYNCTHING_CONFIG_DIR}"
       ;;
+    "wait-healthy")
+      wait_until_healthy
+      ;;
     *)
       error "unknown operation"
       ;;